@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+//Cut planner limits
+const (
+	MaxCutLength = 4
+	MaxCutNodes  = 4000
+	CutBudget    = 15 * time.Millisecond
+)
+
+//CutPlan is a chain of TRAIN actions that disconnects enemy territory
+type CutPlan struct {
+	Tiles  []Point
+	Levels []int
+	Cost   int
+	Upkeep int
+	Score  int
+}
+
+//Actions returns the TRAIN commands of the plan
+func (c CutPlan) Actions() []string {
+	actions := make([]string, len(c.Tiles))
+	for i, p := range c.Tiles {
+		actions[i] = fmt.Sprintf("TRAIN %d %d %d", c.Levels[i], p.X, p.Y)
+	}
+	return actions
+}
+
+//sortPoints orders points by row and column
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Y != points[j].Y {
+			return points[i].Y < points[j].Y
+		}
+		return points[i].X < points[j].X
+	})
+}
+
+//enemyRegion returns the enemy tiles connected to the enemy HQ without the taken tiles
+func (g *GameState) enemyRegion(taken map[Point]bool) map[Point]bool {
+	region := make(map[Point]bool)
+	hqs := g.EnemyBuildings[HQ]
+	if len(hqs) == 0 || taken[hqs[0].Point] {
+		return region
+	}
+	frontier := []Point{hqs[0].Point}
+	region[hqs[0].Point] = true
+	for len(frontier) > 0 {
+		var current Point
+		current, frontier = frontier[0], frontier[1:]
+		for _, next := range g.Map.neighbours(current) {
+			if next.Owner == ENEMY && next.active && !taken[next.Point] && !region[next.Point] {
+				region[next.Point] = true
+				frontier = append(frontier, next.Point)
+			}
+		}
+	}
+	return region
+}
+
+//regionValue weights enemy tiles, units and buildings
+func (g *GameState) regionValue(region map[Point]bool) int {
+	value := 0
+	for p := range region {
+		value++
+		switch e := g.Map.At(p).OccupiedBy.(type) {
+		case Unit:
+			value += unitPrices[e.Level].Train
+		case Building:
+			if e.BuildingType == MINE {
+				value += params.MineValue
+			} else if e.BuildingType == TOWER {
+				value += params.TowerValue
+			}
+		}
+	}
+	return value
+}
+
+type cutSearch struct {
+	g        *GameState
+	base     int
+	direct   map[Point]bool
+	frontier []Point
+	taken    map[Point]bool
+	chain    []Point
+	levels   []int
+	nodes    int
+	deadline time.Time
+	best     *CutPlan
+}
+
+//cuttable reports if a unit trained on the tile could take part in a cut: it is enemy territory
+//or borders it
+func (s *cutSearch) cuttable(tile *Tile) bool {
+	if tile.Owner == ME && tile.active {
+		return false
+	}
+	if tile.Owner == ENEMY {
+		return true
+	}
+	for _, n := range s.g.Map.neighbours(tile.Point) {
+		if n.Owner == ENEMY {
+			return true
+		}
+	}
+	return false
+}
+
+//border returns the cuttable tiles next to our active territory, computed once per search
+func (s *cutSearch) border() []Point {
+	seen := make(map[Point]bool)
+	list := make([]Point, 0)
+	for _, tile := range s.g.Map.Tiles() {
+		if tile.Owner != ME || !tile.active {
+			continue
+		}
+		for _, next := range s.g.Map.neighbours(tile.Point) {
+			if !seen[next.Point] && s.cuttable(next) {
+				seen[next.Point] = true
+				list = append(list, next.Point)
+			}
+		}
+	}
+	return list
+}
+
+//candidates returns tiles a unit could be trained on next in the chain: the border of our
+//territory extended by the neighbours of the chain
+func (s *cutSearch) candidates() []Point {
+	seen := make(map[Point]bool, len(s.frontier))
+	list := make([]Point, 0, len(s.frontier))
+	for _, p := range s.frontier {
+		if !s.taken[p] {
+			seen[p] = true
+			list = append(list, p)
+		}
+	}
+	for _, p := range s.chain {
+		for _, next := range s.g.Map.neighbours(p) {
+			if !seen[next.Point] && !s.taken[next.Point] && s.cuttable(next) {
+				seen[next.Point] = true
+				list = append(list, next.Point)
+			}
+		}
+	}
+	sortPoints(list)
+	return list
+}
+
+//done reports if the search ran out of nodes or time
+func (s *cutSearch) done() bool {
+	return s.nodes >= MaxCutNodes || expired(s.deadline)
+}
+
+func (s *cutSearch) search(economy Economy) {
+	if s.done() || len(s.chain) >= MaxCutLength {
+		return
+	}
+	for _, p := range s.candidates() {
+		tile := s.g.Map.At(p)
+		level := s.g.Map.RequiredLevel(ME, p)
+		if level == Impossible {
+			continue
+		}
+		tiles := 0
+		if tile.Owner != ME || !tile.active {
+			tiles = 1
+		}
+		if !economy.CanTrain(level, tiles) {
+			continue
+		}
+		s.nodes++
+		s.taken[p] = true
+		s.chain = append(s.chain, p)
+		s.levels = append(s.levels, level)
+		s.evaluate()
+		s.search(economy.WithUnit(level, tiles))
+		s.chain = s.chain[:len(s.chain)-1]
+		s.levels = s.levels[:len(s.levels)-1]
+		delete(s.taken, p)
+		if s.done() {
+			return
+		}
+	}
+}
+
+func (s *cutSearch) evaluate() {
+	region := s.g.enemyRegion(s.taken)
+	loss := s.base - s.g.regionValue(region)
+	direct := 0
+	for _, p := range s.chain {
+		if s.direct[p] {
+			direct++
+		}
+	}
+	if len(region) >= len(s.direct)-direct {
+		return
+	}
+	cost, upkeep := 0, 0
+	for _, level := range s.levels {
+		cost += unitPrices[level].Train
+		upkeep += unitPrices[level].Upkeep
+	}
+	if s.best != nil && (loss < s.best.Score || loss == s.best.Score && cost >= s.best.Cost) {
+		return
+	}
+	s.best = &CutPlan{
+		Tiles:  append([]Point{}, s.chain...),
+		Levels: append([]int{}, s.levels...),
+		Cost:   cost,
+		Upkeep: upkeep,
+		Score:  loss,
+	}
+}
+
+//PlanCut searches the TRAIN chain that disconnects the most enemy value within CutBudget and the
+//turn deadline, nil if none cuts
+func (g *GameState) PlanCut() *CutPlan {
+	direct := g.enemyRegion(nil)
+	s := &cutSearch{
+		g:        g,
+		base:     g.regionValue(direct),
+		direct:   direct,
+		taken:    make(map[Point]bool),
+		deadline: g.budget(CutBudget),
+	}
+	s.frontier = s.border()
+	s.search(g.Economy())
+	if s.done() {
+		debug("CUT: search stopped after %d nodes\n", s.nodes)
+	}
+	return s.best
+}
+
+//ApplyCut executes a cut plan on the game state and returns its actions, nil without changing
+//the state if a train of the chain fails, a partial chain costs gold and may cut nothing
+func (g *GameState) ApplyCut(plan *CutPlan) []string {
+	c := g.Clone()
+	actions := make([]string, 0, len(plan.Tiles))
+	for _, p := range plan.Tiles {
+		tile := c.Map.At(p)
+		action := c.Train(tile)
+		if action == "" {
+			debug("CUT: can't train on %s, plan dropped\n", p)
+			return nil
+		}
+		tile.Owner = ME
+		tile.active = true
+		actions = append(actions, action)
+	}
+	c.disconnectEnemy()
+	*g = *c
+	return actions
+}
+
+//disconnectEnemy deactivates enemy tiles cut off from the enemy HQ and kills their units
+func (g *GameState) disconnectEnemy() {
+	region := g.enemyRegion(nil)
+	for _, tile := range g.Map.Tiles() {
+		if tile.Owner == ENEMY && tile.active && !region[tile.Point] {
+			tile.active = false
+			if _, ok := tile.OccupiedBy.(Unit); ok {
+				tile.OccupiedBy = nil
+			}
+		}
+	}
+	alive := make([]*Unit, 0, len(g.EnemyUnits))
+	for _, u := range g.EnemyUnits {
+		if region[u.Point] {
+			alive = append(alive, u)
+		}
+	}
+	g.EnemyUnits = alive
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//cutRows: the enemy HQ corner reaches a blob in the top right through the column x = 11, our
+//territory borders the column from the left
+var cutRows = []string{
+	"OOOOOOOOOXXX",
+	"OOOOOOOOOXXX",
+	"OOOOOOOOOXXX",
+	"OOOOOOOOOOOX",
+	"OOOOOOOOOOOX",
+	"OOOOOOOOOOOX",
+	"OOOOOOOOOOOX",
+	"OOOOOOOOOOOX",
+	"OOOOOOOOXXXX",
+	"OOOOOOOOXXXX",
+	"OOOOOOOOXXXX",
+	"OOOOOOOOXXXX",
+}
+
+var cutBuildings = []string{"0 0 0 0", "1 0 11 11", "1 1 10 1"}
+
+var cutUnits = []string{
+	"1 10 1 9 0",
+	"1 11 1 11 5",
+	"1 12 1 9 9",
+}
+
+func cutBoard(t *testing.T, gold int) *GameState {
+	gs := parseBoard(t, cutRows, cutBuildings, cutUnits)
+	gs.Gold = gold
+	return gs
+}
+
+func TestPlanCut(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	gs := cutBoard(t, 10)
+	plan := gs.PlanCut()
+	if plan == nil {
+		t.Fatal("no cut found")
+	}
+	//the blob and the column down to the cut: 14 tiles, two level 1 units and a mine
+	want := CutPlan{Tiles: []Point{P(11, 7)}, Levels: []int{1}, Cost: 10, Upkeep: 1, Score: 14 + 2*10 + params.MineValue}
+	if !reflect.DeepEqual(*plan, want) {
+		t.Errorf("PlanCut() = %+v, want %+v", *plan, want)
+	}
+	if actions := gs.ApplyCut(plan); !reflect.DeepEqual(actions, []string{"TRAIN 1 11 7"}) {
+		t.Fatalf("ApplyCut() = %v", actions)
+	}
+	for _, p := range []Point{P(9, 0), P(11, 2), P(11, 6)} {
+		if tile := gs.Map.At(p); tile.Owner != ENEMY || tile.active {
+			t.Errorf("%s: owner %d active %v, want cut off enemy territory", p, tile.Owner, tile.active)
+		}
+	}
+	if tile := gs.Map.At(P(11, 8)); !tile.active {
+		t.Errorf("(11, 8) next to the cut was disconnected")
+	}
+	if len(gs.EnemyUnits) != 1 || gs.EnemyUnits[0].ID != 12 {
+		t.Errorf("enemy units %v, want only the unit connected to the HQ", gs.EnemyUnits)
+	}
+	if gs.Map.At(P(9, 0)).OccupiedBy != nil {
+		t.Errorf("cut off unit still on its tile")
+	}
+}
+
+func TestPlanCutLonger(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	gs := cutBoard(t, 20)
+	plan := gs.PlanCut()
+	//two units take the top of the HQ corner, the column and the blob fall with it
+	if want := []Point{P(10, 8), P(11, 8)}; plan == nil || !reflect.DeepEqual(plan.Tiles, want) || plan.Score != 16+2*10+params.MineValue {
+		t.Errorf("PlanCut() = %+v, want %v", plan, want)
+	}
+}
+
+func TestApplyCutUnaffordable(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	gs := cutBoard(t, 10)
+	plan := &CutPlan{Tiles: []Point{P(11, 7), P(11, 8)}, Levels: []int{1, 1}, Cost: 20}
+	if actions := gs.ApplyCut(plan); actions != nil {
+		t.Errorf("ApplyCut() = %v with gold for one unit", actions)
+	}
+	if gs.Gold != 10 || gs.Map.At(P(11, 7)).Owner != ENEMY || len(gs.Units) != 0 {
+		t.Errorf("failed cut changed the state: gold %d, (11, 7) owner %d, units %d", gs.Gold, gs.Map.At(P(11, 7)).Owner, len(gs.Units))
+	}
+}
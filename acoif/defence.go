@@ -0,0 +1,186 @@
+package main
+
+import (
+	"sort"
+)
+
+//Threat is one of our tiles whose loss disconnects territory from the HQ
+type Threat struct {
+	Point
+	Value int
+	Cost  int
+}
+
+//EnemyReachCost returns the gold the enemy needs to capture each tile next turn
+func (g *GameState) EnemyReachCost() map[Point]int {
+	levels := g.Map.Levels(ENEMY)
+	cost := make(map[Point]int)
+	frontier := make([]Point, 0)
+	for _, tile := range g.Map.Tiles() {
+		if tile.Owner == ENEMY && tile.active {
+			cost[tile.Point] = 0
+			frontier = append(frontier, tile.Point)
+		}
+	}
+	for _, unit := range g.EnemyUnits {
+		for _, next := range g.Map.neighbours(unit.Point) {
+			if next.Owner != ENEMY && levels.At(next.Point) <= unit.Level {
+				cost[next.Point] = 0
+				frontier = append(frontier, next.Point)
+			}
+		}
+	}
+	//SPFA: a tile is queued again whenever its cost drops, the board is small
+	for len(frontier) > 0 {
+		var current Point
+		current, frontier = frontier[0], frontier[1:]
+		for _, next := range g.Map.neighbours(current) {
+			if next.Owner == ENEMY && next.active {
+				continue
+			}
+			level := levels.At(next.Point)
+			if level == Impossible {
+				continue
+			}
+			c := cost[current] + unitPrices[level].Train
+			if old, ok := cost[next.Point]; !ok || c < old {
+				cost[next.Point] = c
+				frontier = append(frontier, next.Point)
+			}
+		}
+	}
+	return cost
+}
+
+//tileValue weights a tile with the unit or building on it
+func (g *GameState) tileValue(p Point) int {
+	value := 1
+	switch e := g.Map.At(p).OccupiedBy.(type) {
+	case Unit:
+		value += unitPrices[e.Level].Train
+	case Building:
+		if e.BuildingType == MINE {
+			value += params.MineValue
+		} else if e.BuildingType == TOWER {
+			value += params.TowerValue
+		}
+	}
+	return value
+}
+
+//articulation is a depth first search finding the territory each of our tiles holds connected to the HQ
+type articulation struct {
+	g        *GameState
+	discover map[Point]int
+	low      map[Point]int
+	subtree  map[Point]int
+	lost     map[Point]int
+}
+
+func (a *articulation) visit(p Point) {
+	a.discover[p] = len(a.discover)
+	a.low[p] = a.discover[p]
+	a.subtree[p] = a.g.tileValue(p)
+	a.lost[p] = a.subtree[p]
+	for _, next := range a.g.Map.neighbours(p) {
+		if next.Owner != ME || !next.active {
+			continue
+		}
+		if _, ok := a.discover[next.Point]; ok {
+			if a.discover[next.Point] < a.low[p] {
+				a.low[p] = a.discover[next.Point]
+			}
+			continue
+		}
+		a.visit(next.Point)
+		a.subtree[p] += a.subtree[next.Point]
+		if a.low[next.Point] < a.low[p] {
+			a.low[p] = a.low[next.Point]
+		}
+		if a.low[next.Point] >= a.discover[p] {
+			a.lost[p] += a.subtree[next.Point]
+		}
+	}
+}
+
+//Threats ranks our articulation tiles the enemy can afford to take next turn
+func (g *GameState) Threats() []Threat {
+	hqs := g.Buildings[HQ]
+	if len(hqs) == 0 {
+		return nil
+	}
+	a := &articulation{g, make(map[Point]int), make(map[Point]int), make(map[Point]int), make(map[Point]int)}
+	a.visit(hqs[0].Point)
+	a.lost[hqs[0].Point] = a.subtree[hqs[0].Point]
+	budget := g.EnemyGold + g.EnemyIncome
+	reach := g.EnemyReachCost()
+	threats := make([]Threat, 0)
+	for p, lost := range a.lost {
+		cost, ok := reach[p]
+		if !ok || cost > budget || lost == g.tileValue(p) {
+			continue
+		}
+		threats = append(threats, Threat{p, lost, cost})
+	}
+	sort.Slice(threats, func(i, j int) bool {
+		if threats[i].Value != threats[j].Value {
+			return threats[i].Value > threats[j].Value
+		}
+		if threats[i].Y != threats[j].Y {
+			return threats[i].Y < threats[j].Y
+		}
+		return threats[i].X < threats[j].X
+	})
+	return threats
+}
+
+//Defend builds towers or trains level 3 blockers on the threatened tiles
+func (g *GameState) Defend(threats []Threat) []string {
+	actions := make([]string, 0)
+	value := make(map[Point]int)
+	for _, t := range threats {
+		value[t.Point] = t.Value
+	}
+	for _, t := range threats {
+		tile := g.Map.At(t.Point)
+		if g.Map.Protected(t.Point) {
+			continue
+		}
+		if b, ok := tile.OccupiedBy.(Building); ok && b.BuildingType == TOWER {
+			continue
+		}
+		if u, ok := tile.OccupiedBy.(Unit); ok && u.Level == 3 {
+			continue
+		}
+		var best *Tile
+		bestValue := 0
+		for _, c := range append(g.Map.neighbours(t.Point), tile) {
+			if c.Owner != ME || !c.active || c.OccupiedBy != nil || c.MineSpot {
+				continue
+			}
+			covered := value[c.Point]
+			for _, n := range g.Map.neighbours(c.Point) {
+				if !g.Map.Protected(n.Point) {
+					covered += value[n.Point]
+				}
+			}
+			if covered > bestValue {
+				best, bestValue = c, covered
+			}
+		}
+		if best != nil {
+			if action := g.BuildTower(best); action != "" {
+				debug("DEFEND: %s value:%d tower:%s\n", t.Point, t.Value, best.Point)
+				actions = append(actions, action)
+				continue
+			}
+		}
+		if best == nil && tile.OccupiedBy == nil {
+			if action := g.TrainUnit(tile, 3); action != "" {
+				debug("DEFEND: %s value:%d blocker\n", t.Point, t.Value)
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
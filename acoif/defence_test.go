@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//defenceRows returns a board with our territory from the rows and active enemy territory from
+//column 6 to the enemy HQ
+func defenceRows(rows ...string) []string {
+	board := make([]string, Height)
+	for y := range board {
+		ours := "......"
+		if y < len(rows) {
+			ours = rows[y]
+		}
+		board[y] = ours + "XXXXXX"
+	}
+	return board
+}
+
+//chainRows: a single path from the HQ along row 0 and down column 5
+var chainRows = defenceRows(
+	"OOOOOO",
+	"O....O",
+	".....O",
+	"....OO",
+)
+
+//blockRows: the same path ends in a 2 by 4 block, only its entry holds it
+var blockRows = defenceRows(
+	"OOOOOO",
+	"O...OO",
+	"....OO",
+	"....OO",
+)
+
+var defenceBuildings = []string{"0 0 0 0", "1 0 11 11"}
+
+func TestThreats(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		rows []string
+		want []Threat
+	}{
+		{"chain", chainRows, []Threat{
+			{P(4, 0), 6, 20},
+			{P(5, 0), 5, 10},
+			{P(5, 1), 4, 10},
+			{P(5, 2), 3, 10},
+			{P(5, 3), 2, 10},
+		}},
+		{"cycle", blockRows, []Threat{{P(4, 0), 8, 20}}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gs := parseBoard(t, c.rows, defenceBuildings, nil)
+			if got := gs.Threats(); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Threats() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestThreatsValueUnits(t *testing.T) {
+	gs := parseBoard(t, chainRows, defenceBuildings, []string{"0 1 2 4 3"})
+	threats := gs.Threats()
+	if len(threats) == 0 || threats[0].Point != P(4, 0) || threats[0].Value != 6+unitPrices[2].Train {
+		t.Errorf("Threats() = %v, want (4, 0) first holding the level 2 unit", threats)
+	}
+}
+
+func TestEnemyReachCost(t *testing.T) {
+	gs := parseBoard(t, chainRows, defenceBuildings, []string{"1 7 2 6 0", "0 1 1 5 1"})
+	reach := gs.EnemyReachCost()
+	for p, want := range map[Point]int{
+		P(5, 0): 0,  //the level 2 unit steps on it
+		P(4, 0): 10, //then trains behind it
+		P(5, 1): 20, //our level 1 unit needs a level 2
+		P(3, 0): 20,
+		P(2, 0): 30,
+	} {
+		if got, ok := reach[p]; !ok || got != want {
+			t.Errorf("reach cost of %s = %d %v, want %d", p, got, ok, want)
+		}
+	}
+}
+
+func TestDefendBuildsTower(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	gs := parseBoard(t, chainRows, defenceBuildings, nil)
+	gs.Gold = TowerCost
+	actions := gs.Defend(gs.Threats())
+	if want := []string{"BUILD TOWER 5 0"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("Defend() = %v, want %v", actions, want)
+	}
+	if towers := gs.Buildings[TOWER]; len(towers) != 1 || towers[0].Point != P(5, 0) {
+		t.Errorf("towers %v, want the new tower", towers)
+	}
+	for _, p := range []Point{P(4, 0), P(5, 0), P(5, 1)} {
+		if !gs.Map.Protected(p) {
+			t.Errorf("%s is not protected", p)
+		}
+	}
+}
+
+func TestDefendRushBuildsTower(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	rows := make([]string, Height)
+	for y := range rows {
+		rows[y] = "XXXXXXXXXXXX"
+	}
+	rows[0] = "OOXXXXXXXXXX"
+	rows[1] = "OXXXXXXXXXXX"
+	gs := parseBoard(t, rows, defenceBuildings, nil)
+	gs.Gold = TowerCost
+	if cost, _ := gs.EnemyRush(); cost > gs.EnemyGold+gs.EnemyIncome {
+		t.Fatalf("enemy rush costs %d, the board has no rush", cost)
+	}
+	actions := gs.DefendRush()
+	towers := gs.Buildings[TOWER]
+	if len(actions) != 1 || len(towers) != 1 || actions[0] != (Action{"BUILD", TOWER, towers[0].Point}).String() {
+		t.Errorf("DefendRush() = %v, towers %v", actions, towers)
+	}
+	if cost, _ := gs.EnemyRush(); cost <= gs.EnemyGold+gs.EnemyIncome {
+		t.Errorf("enemy still rushes for %d", cost)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "math"
+
+//MineIncome is the gold a mine yields every turn
+const MineIncome = 4
+
+//Economy is the gold flow of a player
+type Economy struct {
+	Gold   int
+	Income int
+	Upkeep int
+}
+
+//Economy returns our current gold flow
+func (g *GameState) Economy() Economy {
+	upkeep := 0
+	for _, u := range g.Units {
+		upkeep += unitPrices[u.Level].Upkeep
+	}
+	return Economy{g.Gold, g.Income, upkeep}
+}
+
+//Project returns the gold at the start of each of the next turns,
+//a negative balance kills all units and their upkeep
+func (e Economy) Project(turns int) []int {
+	gold := make([]int, turns)
+	for i := range gold {
+		e.Gold += e.Income
+		if e.Gold < 0 {
+			e.Gold = 0
+			e.Income += e.Upkeep
+			e.Upkeep = 0
+		}
+		gold[i] = e.Gold
+	}
+	return gold
+}
+
+//Collapses reports if the gold goes negative within the next turns
+func (e Economy) Collapses(turns int) bool {
+	gold := e.Gold
+	for i := 0; i < turns; i++ {
+		gold += e.Income
+		if gold < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//WithUnit returns the economy after training a unit that captures the given number of tiles
+func (e Economy) WithUnit(level, tiles int) Economy {
+	price := unitPrices[level]
+	return Economy{e.Gold - price.Train, e.Income + tiles - price.Upkeep, e.Upkeep + price.Upkeep}
+}
+
+//WithMine returns the economy after building a mine
+func (e Economy) WithMine(cost int) Economy {
+	return Economy{e.Gold - cost, e.Income + MineIncome, e.Upkeep}
+}
+
+//Payback returns the turns an investment needs to earn its cost back, math.MaxInt32 if never
+func Payback(cost, gain int) int {
+	if gain <= 0 {
+		return math.MaxInt32
+	}
+	return (cost + gain - 1) / gain
+}
+
+//CanTrain reports if a unit is affordable without running into bankruptcy
+func (e Economy) CanTrain(level, tiles int) bool {
+	return e.Gold >= unitPrices[level].Train && !e.WithUnit(level, tiles).Collapses(params.TrainHorizon)
+}
+
+//ShouldBuildMine reports if a mine is affordable and pays back within the horizon
+func (e Economy) ShouldBuildMine(cost int) bool {
+	return e.Gold >= cost && Payback(cost, MineIncome) <= params.MineHorizon && !e.WithMine(cost).Collapses(params.MineHorizon)
+}
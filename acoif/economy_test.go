@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		e     Economy
+		turns int
+		gold  []int
+	}{
+		{"steady", Economy{10, 3, 1}, 3, []int{13, 16, 19}},
+		{"no turns", Economy{10, 3, 1}, 0, []int{}},
+		{"bankruptcy recovers without upkeep", Economy{5, -3, 10}, 4, []int{2, 0, 7, 14}},
+		{"bankruptcy without units stays broke", Economy{0, -2, 0}, 2, []int{0, 0}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.e.Project(c.turns); !reflect.DeepEqual(got, c.gold) {
+				t.Errorf("Project(%d) = %v, want %v", c.turns, got, c.gold)
+			}
+		})
+	}
+}
+
+func TestPayback(t *testing.T) {
+	for _, c := range []struct{ cost, gain, turns int }{
+		{20, 4, 5},
+		{21, 4, 6},
+		{10, 0, math.MaxInt32},
+		{10, -1, math.MaxInt32},
+	} {
+		if got := Payback(c.cost, c.gain); got != c.turns {
+			t.Errorf("Payback(%d, %d) = %d, want %d", c.cost, c.gain, got, c.turns)
+		}
+	}
+}
+
+func TestCanTrain(t *testing.T) {
+	defer func(p Parameters) { params = p }(params)
+	params = DefaultParameters
+	params.TrainHorizon = 20
+	for _, c := range []struct {
+		name         string
+		e            Economy
+		level, tiles int
+		want         bool
+	}{
+		{"not enough gold", Economy{9, 5, 0}, 1, 1, false},
+		{"upkeep outgrows income", Economy{30, 5, 0}, 3, 1, false},
+		{"captured tile pays the upkeep", Economy{10, 0, 0}, 1, 1, true},
+		{"no tile leaves a deficit", Economy{10, 0, 0}, 1, 0, false},
+		{"reserve covers the deficit over the horizon", Economy{40, 0, 0}, 1, 0, true},
+		{"reserve runs out within the horizon", Economy{25, 0, 0}, 1, 0, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.e.CanTrain(c.level, c.tiles); got != c.want {
+				t.Errorf("%+v.CanTrain(%d, %d) = %v, want %v", c.e, c.level, c.tiles, got, c.want)
+			}
+		})
+	}
+}
+
+func TestShouldBuildMine(t *testing.T) {
+	defer func(p Parameters) { params = p }(params)
+	params = DefaultParameters
+	params.MineHorizon = 20
+	for _, c := range []struct {
+		name string
+		e    Economy
+		cost int
+		want bool
+	}{
+		{"not enough gold", Economy{19, 5, 0}, 20, false},
+		{"pays back too late", Economy{100, 5, 0}, 84, false},
+		{"pays back at the horizon", Economy{100, 5, 0}, 80, true},
+		{"mine cannot stop a collapse", Economy{20, -5, 0}, 20, false},
+		{"affordable", Economy{20, 0, 0}, 20, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.e.ShouldBuildMine(c.cost); got != c.want {
+				t.Errorf("%+v.ShouldBuildMine(%d) = %v, want %v", c.e, c.cost, got, c.want)
+			}
+		})
+	}
+}
+
+func TestEconomyUpkeep(t *testing.T) {
+	b := protectionBoard
+	units := append([]string{}, b.units...)
+	units = append(units, "0 2 3 3 3")
+	gs := parseBoard(t, b.rows, b.buildings, units)
+	want := Economy{gs.Gold, gs.Income, unitPrices[1].Upkeep + unitPrices[3].Upkeep}
+	if got := gs.Economy(); got != want {
+		t.Errorf("Economy() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+//Rush limits
+const (
+	MaxRushBlocks   = 3                     //towers and blockers placed against one enemy rush
+	MaxLethalMovers = 4                     //units closest to the enemy HQ whose moves are combined
+	LethalBudget    = 10 * time.Millisecond //time the lethal search may take of the turn
+)
+
+type costItem struct {
+	Point
+	cost int
+}
+
+//costQueue orders tiles by path cost, ties by row and column
+type costQueue []costItem
+
+func (q costQueue) Len() int      { return len(q) }
+func (q costQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q costQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	if q[i].Y != q[j].Y {
+		return q[i].Y < q[j].Y
+	}
+	return q[i].X < q[j].X
+}
+
+func (q *costQueue) Push(x interface{}) { *q = append(*q, x.(costItem)) }
+func (q *costQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+//RushPath returns the cheapest chain of trains from the active territory of a player to the target
+//and its gold cost, nil if there is none. Tiles next to a mover it can step on are free, which
+//overestimates a rush when one unit has several such tiles.
+func (m *TileMap) RushPath(player int, target Point, movers []*Unit) (int, []Point) {
+	levels := m.Levels(player)
+	cost := make(map[Point]int)
+	parent := make(map[Point]Point)
+	done := make(map[Point]bool)
+	queue := make(costQueue, 0)
+	for _, tile := range m.Tiles() {
+		if tile.Owner == player && tile.active {
+			cost[tile.Point] = 0
+		}
+	}
+	for _, u := range movers {
+		for _, next := range m.neighbours(u.Point) {
+			if levels.At(next.Point) <= u.Level {
+				cost[next.Point] = 0
+			}
+		}
+	}
+	for p, c := range cost {
+		queue = append(queue, costItem{p, c})
+	}
+	heap.Init(&queue)
+	for {
+		if queue.Len() == 0 {
+			return 0, nil
+		}
+		current := heap.Pop(&queue).(costItem)
+		if done[current.Point] {
+			continue
+		}
+		if current.Point == target {
+			break
+		}
+		done[current.Point] = true
+		for _, next := range m.neighbours(current.Point) {
+			if done[next.Point] {
+				continue
+			}
+			step := 0
+			if next.Owner != player || !next.active {
+				level := levels.At(next.Point)
+				if level == Impossible {
+					continue
+				}
+				step = unitPrices[level].Train
+			}
+			if c, ok := cost[next.Point]; !ok || current.cost+step < c {
+				cost[next.Point] = current.cost + step
+				parent[next.Point] = current.Point
+				heap.Push(&queue, costItem{next.Point, current.cost + step})
+			}
+		}
+	}
+	path := make([]Point, 0)
+	for p, ok := target, true; ok && cost[p] > 0; p, ok = parent[p] {
+		path = append([]Point{p}, path...)
+	}
+	return cost[target], path
+}
+
+//forceTrain trains a unit ignoring the economy, for moves that decide the game. Income is
+//updated like in TrainUnit so later spending this turn sees the upkeep.
+func (g *GameState) forceTrain(tile *Tile, level int) string {
+	if g.Gold < unitPrices[level].Train {
+		return ""
+	}
+	tiles := 0
+	if tile.Owner != ME || !tile.active {
+		tiles = 1
+	}
+	unit := &Unit{tile.Point, ME, -1, level}
+	g.Gold -= unitPrices[level].Train
+	g.Income += tiles - unitPrices[level].Upkeep
+	tile.OccupiedBy = *unit
+	tile.Owner = ME
+	tile.active = true
+	g.Units = append(g.Units, unit)
+	g.disconnectEnemy()
+	return fmt.Sprintf("TRAIN %d %d %d", level, tile.X, tile.Y)
+}
+
+//trainChain trains along a path from our territory, false if a step is illegal or unaffordable
+func (g *GameState) trainChain(path []Point) ([]string, bool) {
+	actions := make([]string, 0, len(path))
+	for _, p := range path {
+		tile := g.Map.At(p)
+		level := g.Map.RequiredLevel(ME, p)
+		if level == Impossible || !g.trainable(tile) {
+			return nil, false
+		}
+		action := g.forceTrain(tile, level)
+		if action == "" {
+			return nil, false
+		}
+		actions = append(actions, action)
+	}
+	return actions, true
+}
+
+//lethalMove moves a unit onto a tile
+type lethalMove struct {
+	id int
+	to Point
+}
+
+//captureMoves returns the tiles closer to the target next to a unit that are not our territory,
+//their level is checked when the moves are played since earlier moves can kill their guards
+func (g *GameState) captureMoves(u *Unit, target Point) []Point {
+	moves := make([]Point, 0)
+	for _, next := range g.Map.neighbours(u.Point) {
+		if !(next.Owner == ME && next.active) && next.distance(target) < u.distance(target) {
+			moves = append(moves, next.Point)
+		}
+	}
+	return moves
+}
+
+//tryLethal plays the moves on a copy and trains the cheapest chain to the enemy HQ, it returns
+//the actions and the copy, nil if the moves are illegal in every order or the chain is
+//unaffordable. A move that only becomes legal once another move cut its guard off the enemy HQ
+//is played after it.
+func (g *GameState) tryLethal(moves []lethalMove) ([]string, *GameState) {
+	hq := g.EnemyBuildings[HQ][0].Point
+	c := g.Clone()
+	actions := make([]string, 0)
+	pending := append([]lethalMove{}, moves...)
+	for len(pending) > 0 {
+		left := pending[:0]
+		for _, m := range pending {
+			var unit *Unit
+			for _, u := range c.Units {
+				if u.ID == m.id {
+					unit = u
+				}
+			}
+			tile := c.Map.At(m.to)
+			if unit == nil || tile == nil || c.Map.RequiredLevel(ME, m.to) > unit.Level {
+				left = append(left, m)
+				continue
+			}
+			actions = append(actions, c.moveUnit(unit, tile))
+			if m.to == hq {
+				return actions, c
+			}
+		}
+		if len(left) == len(pending) {
+			return nil, nil
+		}
+		pending = left
+	}
+	cost, path := c.Map.RushPath(ME, hq, nil)
+	if path == nil || cost > c.Gold {
+		return nil, nil
+	}
+	chain, ok := c.trainChain(path)
+	if !ok {
+		return nil, nil
+	}
+	return append(actions, chain...), c
+}
+
+//Lethal returns the moves and trains that capture the enemy HQ this turn and applies them, nil if
+//there are none. It combines the moves of the MaxLethalMovers units closest to the enemy HQ, staying units first,
+//within LethalBudget and the turn deadline.
+func (g *GameState) Lethal() []string {
+	deadline := g.budget(LethalBudget)
+	hq := g.EnemyBuildings[HQ][0].Point
+	movers := make([]*Unit, 0, len(g.Units))
+	for _, u := range g.Units {
+		if u.ID != -1 && len(g.captureMoves(u, hq)) > 0 {
+			movers = append(movers, u)
+		}
+	}
+	sort.SliceStable(movers, func(i, j int) bool { return movers[i].distance(hq) < movers[j].distance(hq) })
+	if len(movers) > MaxLethalMovers {
+		movers = movers[:MaxLethalMovers]
+	}
+	moves := make([]lethalMove, 0, len(movers))
+	var found []string
+	var won *GameState
+	var search func(i int) bool
+	search = func(i int) bool {
+		if expired(deadline) {
+			debug("LETHAL: search stopped at %v\n", moves)
+			return true
+		}
+		if i == len(movers) {
+			found, won = g.tryLethal(moves)
+			return found != nil
+		}
+		if search(i + 1) {
+			return true
+		}
+		for _, to := range g.captureMoves(movers[i], hq) {
+			moves = append(moves, lethalMove{movers[i].ID, to})
+			stop := search(i + 1)
+			moves = moves[:len(moves)-1]
+			if stop {
+				return true
+			}
+		}
+		return false
+	}
+	search(0)
+	if found != nil {
+		debug("LETHAL: %v\n", found)
+		*g = *won
+	}
+	return found
+}
+
+//EnemyRush returns the gold the enemy needs to reach our HQ next turn and the tiles it trains on
+func (g *GameState) EnemyRush() (int, []Point) {
+	cost, path := g.Map.RushPath(ENEMY, g.Buildings[HQ][0].Point, g.EnemyUnits)
+	if path == nil && cost == 0 {
+		return math.MaxInt32, nil
+	}
+	return cost, path
+}
+
+//DefendRush builds towers or trains blockers while the enemy can afford to reach our HQ
+func (g *GameState) DefendRush() []string {
+	actions := make([]string, 0)
+	budget := g.EnemyGold + g.EnemyIncome
+	for i := 0; i < MaxRushBlocks; i++ {
+		cost, path := g.EnemyRush()
+		if cost > budget {
+			break
+		}
+		debug("RUSH: enemy reaches HQ for %d via %v\n", cost, path)
+		var best *GameState
+		var bestAction string
+		bestCost := cost
+		consider := func(c *GameState, action string) {
+			if action == "" {
+				return
+			}
+			if after, _ := c.EnemyRush(); after > bestCost || after == bestCost && best != nil && c.Gold > best.Gold {
+				best, bestAction, bestCost = c, action, after
+			}
+		}
+		for _, p := range path {
+			tile := g.Map.At(p)
+			for _, t := range append(g.Map.neighbours(p), tile) {
+				if t.Owner != ME || !t.active || t.OccupiedBy != nil || t.MineSpot || g.Gold < TowerCost {
+					continue
+				}
+				c := g.Clone()
+				consider(c, c.BuildTower(c.Map.At(t.Point)))
+			}
+			if !g.trainable(tile) {
+				continue
+			}
+			for level := g.Map.RequiredLevel(ME, p); level <= 3; level++ {
+				c := g.Clone()
+				consider(c, c.forceTrain(c.Map.At(p), level))
+			}
+		}
+		if best == nil {
+			break
+		}
+		debug("RUSH: %s raises the cost to %d\n", bestAction, bestCost)
+		*g = *best
+		actions = append(actions, bestAction)
+	}
+	return actions
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForceTrainIncome(t *testing.T) {
+	gs := openingBoard(t, nil, nil)
+	gs.Gold = 60
+	income := gs.Income
+	if action := gs.forceTrain(gs.Map.At(P(1, 0)), 3); action != "TRAIN 3 1 0" {
+		t.Fatalf("forceTrain = %q", action)
+	}
+	if want := income + 1 - unitPrices[3].Upkeep; gs.Income != want {
+		t.Errorf("income %d after a level 3 blocker, want %d", gs.Income, want)
+	}
+	if gs.Economy().CanTrain(1, 1) {
+		t.Errorf("CanTrain approves spending with the blocker's upkeep unpaid")
+	}
+}
+
+//lethalRows: our territory surrounds an enemy corridor from the HQ at (11, 11) to (9, 11), the
+//neutral (10, 11) separates them
+var lethalRows = []string{
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOOOO",
+	"OOOOOOOOOXXX",
+	"OOOOOOOOOX.X",
+}
+
+var lethalUnits = []string{
+	"1 20 1 9 10",  //guard A can take
+	"1 21 3 10 10", //guards nobody can take
+	"1 22 3 11 10",
+	"1 23 3 9 11", //dies when A cuts (9, 11) off the HQ
+	"0 1 2 9 9",   //A
+	"0 2 1 8 11",  //B
+}
+
+func TestLethal(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	for _, c := range []struct {
+		name string
+		gold int
+		want []string
+	}{
+		{"two moves enable the rush", 20, []string{"MOVE 1 9 10", "MOVE 2 9 11", "TRAIN 1 10 11", "TRAIN 1 11 11"}},
+		{"one move and trains", 30, []string{"MOVE 1 9 10", "TRAIN 1 9 11", "TRAIN 1 10 11", "TRAIN 1 11 11"}},
+		{"unaffordable", 10, nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gs := parseBoard(t, lethalRows, []string{"0 0 0 0", "1 0 11 11"}, lethalUnits)
+			gs.Gold = c.gold
+			if got := gs.Lethal(); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Lethal() = %v, want %v", got, c.want)
+			}
+			if won := gs.Map.At(P(11, 11)).Owner == ME; won != (c.want != nil) {
+				t.Errorf("enemy HQ captured: %v, want %v", won, c.want != nil)
+			}
+		})
+	}
+}
+
+func TestSearchTurnKeepsLethal(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	gs := parseBoard(t, lethalRows, []string{"0 0 0 0", "1 0 11 11"}, lethalUnits)
+	gs.Gold = 30
+	want := gs.Clone().Turn(20)
+	if got := gs.SearchTurn(20); !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchTurn(20) = %v, want the lethal %v", got, want)
+	}
+}
+
+func TestRushPath(t *testing.T) {
+	gs := parseBoard(t, lethalRows, []string{"0 0 0 0", "1 0 11 11"}, lethalUnits)
+	cost, path := gs.Map.RushPath(ME, P(11, 11), nil)
+	if want := []Point{P(11, 10), P(11, 11)}; cost != 40 || !reflect.DeepEqual(path, want) {
+		t.Errorf("RushPath = %d %v, want 40 %v", cost, path, want)
+	}
+}
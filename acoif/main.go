@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"sort"
 	"strings"
+	"time"
 )
 
 //Constants
@@ -40,7 +42,7 @@ var unitPrices = make(map[int]UnitCost)
 
 // fmt.Fprintln(os.Stderr, "Debug messages...")
 func debug(format string, a ...interface{}) {
-	if DEBUG {
+	if DEBUG && !quiet {
 		fmt.Fprintf(os.Stderr, format, a...)
 	}
 }
@@ -52,18 +54,102 @@ func init() {
 
 }
 
+var selfplay = flag.Int("selfplay", 0, "play n local referee matches against the greedy bot")
+var record = flag.String("record", "", "dump every turn input and the actions to a replay file")
+var replay = flag.String("replay", "", "rerun a replay file and report turns with different actions")
+var search = flag.Bool("search", false, "use the time bounded search instead of the greedy turn")
+var render = flag.Bool("render", false, "draw the map of every turn to stderr")
+var svgDir = flag.String("svg", "", "write the map of every turn as SVG into a directory")
+var tune = flag.Int("tune", 0, "tune the greedy bot parameters with n SPSA iterations of local self-play")
+var tunePairs = flag.Int("tune-pairs", 10, "game pairs with swapped sides per tuning batch")
+
 func main() {
-	var numberMineSpots int
-	fmt.Scan(&numberMineSpots)
-	mines := make(map[Point]Point)
-	for i := 0; i < numberMineSpots; i++ {
-		mine := ParseMineSpot()
-		mines[mine] = mine
+	flag.Parse()
+	greedy := Normalised((*GameState).Turn)
+	bot := greedy
+	if *search {
+		bot = Normalised((*GameState).SearchTurn)
+	}
+	if *tune > 0 {
+		fmt.Printf("tuned: %+v\n", Tune(*tune, *tunePairs))
+		return
+	}
+	if *selfplay > 0 {
+		SelfPlay(*selfplay, [2]Strategy{bot, greedy})
+		return
+	}
+	if *replay != "" {
+		rp, err := LoadReplayFile(*replay)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *svgDir != "" {
+			for n, turn := range rp.Turns {
+				gs, err := rp.State(n)
+				if err == nil {
+					err = gs.WriteSVG(*svgDir, n, fmt.Sprintf("turn %d: %s", n, turn.Actions))
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			}
+		}
+		diff := rp.Verify(bot)
+		fmt.Printf("%d/%d turns differ: %v\n", len(diff), len(rp.Turns), diff)
+		return
+	}
+	in := NewInputReader(os.Stdin)
+	init, err := ReadInitBlock(in)
+	if err != nil {
+		debug("init: %v\n", err)
+	}
+	mines, err := ParseMineSpots(strings.NewReader(init))
+	if err != nil {
+		debug("init: %v\n", err)
+	}
+	var rec *Recorder
+	if *record != "" {
+		var err error
+		if rec, err = NewRecorder(*record, init); err != nil {
+			debug("record: %v\n", err)
+		}
+		defer rec.Close()
 	}
 	n := 0
 	for {
-		gs := ParseGameState(mines)
-		fmt.Println(strings.Join(gs.Turn(n), ";")) // Write action to stdout
+		start := in.Lines()
+		input, err := ReadTurnBlock(in)
+		if err == io.EOF {
+			return
+		}
+		var gs *GameState
+		if err == nil {
+			gs, err = ParseGameState(NewInputReaderAt(strings.NewReader(input), start), mines)
+		}
+		if err != nil {
+			debug("turn %d: %v\n", n, err)
+			rec.Record(n, input, "WAIT")
+			fmt.Println("WAIT")
+			n++
+			continue
+		}
+		var view *GameState
+		if *render || *svgDir != "" {
+			view = gs.Clone()
+		}
+		actions := strings.Join(bot(gs, n), ";")
+		rec.Record(n, input, actions)
+		if *render {
+			debug("%s", view.Render())
+		}
+		if *svgDir != "" {
+			if err := view.WriteSVG(*svgDir, n, fmt.Sprintf("turn %d: %s", n, actions)); err != nil {
+				debug("svg: %v\n", err)
+			}
+		}
+		fmt.Println(actions) // Write action to stdout
 		n++
 	}
 }
@@ -83,11 +169,14 @@ func (p Point) add(b Point) Point {
 }
 
 func (p Point) distance(b Point) int {
-	d := p.X - b.X + p.Y - b.Y
-	if d < 0 {
-		return d * -1
+	dx, dy := p.X-b.X, p.Y-b.Y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
 	}
-	return d
+	return dx + dy
 }
 
 func (p Point) String() string {
@@ -99,11 +188,31 @@ type Entity interface {
 	Position() Point
 }
 
-//ParseMineSpot from STDIN
-func ParseMineSpot() Point {
-	var x, y int
-	fmt.Scan(&x, &y)
-	return Point{x, y}
+//ParseMineSpot from input
+func ParseMineSpot(in *InputReader) (Point, error) {
+	v, err := in.Ints("mine spot", 2)
+	if err != nil {
+		return Point{}, err
+	}
+	return in.Point("mine spot", v[0], v[1])
+}
+
+//ParseMineSpots reads the initialization input
+func ParseMineSpots(r io.Reader) (map[Point]Point, error) {
+	in := NewInputReader(r)
+	numberMineSpots, err := in.Count("numberMineSpots")
+	if err != nil {
+		return nil, err
+	}
+	mines := make(map[Point]Point)
+	for i := 0; i < numberMineSpots; i++ {
+		mine, err := ParseMineSpot(in)
+		if err != nil {
+			return nil, err
+		}
+		mines[mine] = mine
+	}
+	return mines, nil
 }
 
 //Tile Gamemap structure
@@ -113,7 +222,6 @@ type Tile struct {
 	active     bool
 	MineSpot   bool
 	OccupiedBy Entity
-	DefendedBy *Building
 }
 
 func (t Tile) String() string {
@@ -133,7 +241,7 @@ func NewTile(x, y int, tile string, mines map[Point]Point) *Tile {
 	}
 	active := tile == strings.ToUpper(tile)
 
-	t := &Tile{Point{x, y}, owner, active, false, nil, nil}
+	t := &Tile{Point{x, y}, owner, active, false, nil}
 
 	if _, ok := mines[t.Point]; ok {
 		t.MineSpot = true
@@ -154,10 +262,20 @@ func (b Building) Position() Point {
 }
 
 //NewBuilding Constructor
-func NewBuilding() *Building {
-	var owner, buildingType, x, y int
-	fmt.Scan(&owner, &buildingType, &x, &y)
-	return &Building{Point{x, y}, owner, buildingType}
+func NewBuilding(in *InputReader) (*Building, error) {
+	v, err := in.Ints("building", 4)
+	if err != nil {
+		return nil, err
+	}
+	owner, buildingType := v[0], v[1]
+	if owner != ME && owner != ENEMY {
+		return nil, in.Errorf("building owner", "invalid owner %d", owner)
+	}
+	if buildingType != HQ && buildingType != MINE && buildingType != TOWER {
+		return nil, in.Errorf("buildingType", "invalid building type %d", buildingType)
+	}
+	p, err := in.Point("building", v[2], v[3])
+	return &Building{p, owner, buildingType}, err
 }
 
 //Unit Entity
@@ -173,27 +291,81 @@ func (u Unit) Position() Point {
 	return u.Point
 }
 
-//ParseUnit from STDIN
-func ParseUnit() *Unit {
-	var owner, unitID, level, x, y int
-	fmt.Scan(&owner, &unitID, &level, &x, &y)
-	return &Unit{Point{x, y}, owner, unitID, level}
+//ParseUnit from input
+func ParseUnit(in *InputReader) (*Unit, error) {
+	v, err := in.Ints("unit", 5)
+	if err != nil {
+		return nil, err
+	}
+	owner, unitID, level := v[0], v[1], v[2]
+	if owner != ME && owner != ENEMY {
+		return nil, in.Errorf("unit owner", "invalid owner %d", owner)
+	}
+	if level < 1 || level > 3 {
+		return nil, in.Errorf("level", "invalid level %d", level)
+	}
+	p, err := in.Point("unit", v[3], v[4])
+	return &Unit{p, owner, unitID, level}, err
+}
+
+//TileMap game playfield, an array indexed by row and column so every iteration is deterministic
+type TileMap [Height][Width]*Tile
+
+//Inside reports if a point lies on the board
+func Inside(p Point) bool {
+	return p.X >= 0 && p.X < Width && p.Y >= 0 && p.Y < Height
+}
+
+//At returns the tile of a point, nil for void tiles and points off the board
+func (m *TileMap) At(p Point) *Tile {
+	if !Inside(p) {
+		return nil
+	}
+	return m[p.Y][p.X]
+}
+
+//Set places a tile on the board
+func (m *TileMap) Set(t *Tile) {
+	m[t.Y][t.X] = t
+}
+
+//Tiles returns the tiles ordered by row and column
+func (m *TileMap) Tiles() []*Tile {
+	tiles := make([]*Tile, 0, Width*Height)
+	for _, row := range m {
+		for _, tile := range row {
+			if tile != nil {
+				tiles = append(tiles, tile)
+			}
+		}
+	}
+	return tiles
 }
 
-//TileMap game playfield
-type TileMap map[Point]*Tile
+//Len returns the number of tiles
+func (m *TileMap) Len() int {
+	return len(m.Tiles())
+}
 
-func (m TileMap) neighbours(p Point) []*Tile {
+func (m *TileMap) neighbours(p Point) []*Tile {
 	var tiles []*Tile
 	for _, dir := range dirs {
-		neighbor := p.add(dir)
-		if tile, ok := m[neighbor]; ok {
+		if tile := m.At(p.add(dir)); tile != nil {
 			tiles = append(tiles, tile)
 		}
 	}
 	return tiles
 }
 
+//SortedPoints returns the points of the map ordered by row and column
+func (m *TileMap) SortedPoints() []Point {
+	points := make([]Point, 0, Width*Height)
+	for _, tile := range m.Tiles() {
+		points = append(points, tile.Point)
+	}
+	return points
+}
+
 //BFS breath first search
 func (m *TileMap) BFS(start Point, until func(c Point) bool) map[Point]int {
 	frontier := []Point{start}
@@ -215,7 +387,25 @@ func (m *TileMap) BFS(start Point, until func(c Point) bool) map[Point]int {
 	return distance
 }
 
-//ByDistance Sorter
+//Layers returns the tiles grouped by their BFS distance from start, each layer ordered by row and column
+func (m *TileMap) Layers(start Point) [][]*Tile {
+	distance := m.BFS(start, func(c Point) bool { return false })
+	layers := make([][]*Tile, 0)
+	for _, tile := range m.Tiles() {
+		d, ok := distance[tile.Point]
+		if !ok {
+			continue
+		}
+		for len(layers) <= d {
+			layers = append(layers, make([]*Tile, 0))
+		}
+		layers[d] = append(layers[d], tile)
+	}
+	return layers
+}
+
+//ByDistance Sorter, ties are broken by row and column, unreachable tiles go last. It orders other
+//tile lists like TilesSortedByDistanceFrom.
 type ByDistance struct {
 	distance map[Point]int
 	tiles    []*Tile
@@ -224,25 +414,39 @@ type ByDistance struct {
 func (b ByDistance) Len() int      { return len(b.tiles) }
 func (b ByDistance) Swap(i, j int) { b.tiles[i], b.tiles[j] = b.tiles[j], b.tiles[i] }
 func (b ByDistance) Less(i, j int) bool {
-	if b.distance[b.tiles[i].Point] < b.distance[b.tiles[j].Point] {
-		return true
+	di, dj := b.at(i), b.at(j)
+	if di != dj {
+		return di < dj
 	}
-	if b.tiles[i].Y < b.tiles[j].Y {
-		return true
+	if b.tiles[i].Y != b.tiles[j].Y {
+		return b.tiles[i].Y < b.tiles[j].Y
 	}
 	return b.tiles[i].X < b.tiles[j].X
 }
 
-//TilesSortedByDistanceFrom point
-func (m TileMap) TilesSortedByDistanceFrom(p Point) []*Tile {
-	distance := m.BFS(p, func(c Point) bool { return false })
-	tiles := make([]*Tile, len(m))
-	idx := 0
-	for _, tile := range m {
-		tiles[idx] = tile
-		idx++
+func (b ByDistance) at(i int) int {
+	if d, ok := b.distance[b.tiles[i].Point]; ok {
+		return d
+	}
+	return Width * Height
+}
+
+//TilesSortedByDistanceFrom point, the distance layers followed by the unreachable tiles in row and
+//column order
+func (m *TileMap) TilesSortedByDistanceFrom(p Point) []*Tile {
+	tiles := make([]*Tile, 0, Width*Height)
+	reached := make(map[Point]bool)
+	for _, layer := range m.Layers(p) {
+		for _, tile := range layer {
+			tiles = append(tiles, tile)
+			reached[tile.Point] = true
+		}
+	}
+	for _, tile := range m.Tiles() {
+		if !reached[tile.Point] {
+			tiles = append(tiles, tile)
+		}
 	}
-	sort.Stable(ByDistance{distance, tiles})
 	return tiles
 }
 
@@ -260,39 +464,66 @@ type GameState struct {
 	Units          []*Unit
 	EnemyBuildings BuildingTypeMap
 	EnemyUnits     []*Unit
+	Deadline       time.Time //zero means no time limit
 }
 
 var enemyPos Point
 
-//ParseGameState from STDIN
-func ParseGameState(mines map[Point]Point) *GameState {
+//ParseGameState from input, malformed input is reported as ParseError
+func ParseGameState(in *InputReader, mines map[Point]Point) (*GameState, error) {
 	gs := &GameState{}
-	fmt.Scan(&gs.Gold)
-	fmt.Scan(&gs.Income)
-
-	fmt.Scan(&gs.EnemyGold)
-	fmt.Scan(&gs.EnemyIncome)
-	gs.Map = make(TileMap)
+	var err error
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{
+		{"gold", &gs.Gold},
+		{"income", &gs.Income},
+		{"opponentGold", &gs.EnemyGold},
+		{"opponentIncome", &gs.EnemyIncome},
+	} {
+		if *field.value, err = in.Int(field.name); err != nil {
+			return nil, err
+		}
+	}
 	gs.Buildings = make(BuildingTypeMap)
 	gs.EnemyBuildings = make(BuildingTypeMap)
 	gs.Units = make([]*Unit, 0)
 	gs.EnemyUnits = make([]*Unit, 0)
-	for i := 0; i < 12; i++ {
-		var line string
-		fmt.Scan(&line)
+	for i := 0; i < Height; i++ {
+		line, err := in.Next("map")
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if len(line) != Width {
+			return nil, in.Errorf("map", "row %d has %d tiles, expected %d", i, len(line), Width)
+		}
 		cols := strings.Split(line, "")
 		for j, c := range cols {
+			if !strings.Contains("#.oOxX", c) {
+				return nil, in.Errorf("map", "invalid tile %q at %s", c, P(j, i))
+			}
 			tile := NewTile(j, i, c, mines)
 			if tile != nil {
-				gs.Map[tile.Point] = tile
+				gs.Map.Set(tile)
 			}
 		}
 	}
-	var buildingCount int
-	fmt.Scan(&buildingCount)
+	buildingCount, err := in.Count("buildingCount")
+	if err != nil {
+		return nil, err
+	}
 
 	for i := 0; i < buildingCount; i++ {
-		building := NewBuilding()
+		building, err := NewBuilding(in)
+		if err != nil {
+			return nil, err
+		}
+		tile := gs.Map.At(building.Point)
+		if tile == nil || tile.OccupiedBy != nil {
+			return nil, in.Errorf("building", "%s is void or occupied", building.Point)
+		}
 		var buildList map[int][]*Building
 		buildList = gs.Buildings
 		if building.Owner == ENEMY {
@@ -302,32 +533,36 @@ func ParseGameState(mines map[Point]Point) *GameState {
 			buildList[building.BuildingType] = make([]*Building, 0)
 		}
 		buildList[building.BuildingType] = append(buildList[building.BuildingType], building)
-		if building.BuildingType == TOWER {
-			next := gs.Map.neighbours(building.Point)
-			for _, tile := range next {
-				if tile.Owner == building.Owner && tile.active {
-					tile.DefendedBy = building
-				}
-			}
-		}
-		gs.Map[building.Point].OccupiedBy = *building
+		tile.OccupiedBy = *building
+	}
+	unitCount, err := in.Count("unitCount")
+	if err != nil {
+		return nil, err
 	}
-	var unitCount int
-	fmt.Scan(&unitCount)
 
 	for i := 0; i < unitCount; i++ {
-		unit := ParseUnit()
+		unit, err := ParseUnit(in)
+		if err != nil {
+			return nil, err
+		}
+		tile := gs.Map.At(unit.Point)
+		if tile == nil || tile.OccupiedBy != nil {
+			return nil, in.Errorf("unit", "%s is void or occupied", unit.Point)
+		}
 		if unit.Owner == ME {
 			gs.Units = append(gs.Units, unit)
 
 		} else if unit.Owner == ENEMY {
 			gs.EnemyUnits = append(gs.EnemyUnits, unit)
 		}
-		gs.Map[unit.Point].OccupiedBy = *unit
+		tile.OccupiedBy = *unit
 	}
 
+	if len(gs.Buildings[HQ]) != 1 || len(gs.EnemyBuildings[HQ]) != 1 {
+		return nil, in.Errorf("buildings", "expected one HQ per player")
+	}
 	enemyPos = gs.EnemyBuildings[HQ][0].Point
-	return gs
+	return gs, nil
 }
 
 func (g GameState) mineCost() int {
@@ -336,120 +571,63 @@ func (g GameState) mineCost() int {
 
 //Train action
 func (g *GameState) Train(tile *Tile) string {
-	var level = 1
-	if tile.DefendedBy != nil {
-		level = 3
-	} else if u, ok := tile.OccupiedBy.(Unit); ok {
-		level = u.Level + 1
-	} else if b, ok := tile.OccupiedBy.(Building); ok {
-		if b.BuildingType == TOWER {
-			level = 3
-		}
+	level := g.Map.RequiredLevel(ME, tile.Point)
+	if level == Impossible {
+		return ""
 	}
-	if level > 3 {
-		level = 3
+	action := g.TrainUnit(tile, level)
+	if action != "" {
+		debug("TRAINING: %#v\n", tile)
+	}
+	return action
+}
+
+//TrainUnit trains a unit of the given level
+func (g *GameState) TrainUnit(tile *Tile, level int) string {
+	tiles := 0
+	if tile.Owner != ME || !tile.active {
+		tiles = 1
 	}
-	if g.Gold >= unitPrices[level].Train && g.Income >= unitPrices[level].Upkeep {
+	if g.Economy().CanTrain(level, tiles) {
 		unit := &Unit{tile.Point, ME, -1, level}
 		g.Gold -= unitPrices[level].Train
-		g.Income -= unitPrices[level].Upkeep
-		if tile.Owner != ME || tile.Owner == ME && !tile.active {
-			g.Income++
-		}
+		g.Income += tiles - unitPrices[level].Upkeep
 		tile.OccupiedBy = *unit
 		g.Units = append(g.Units, unit)
-		debug("TRAINING: %#v\n", tile)
 		return fmt.Sprintf("TRAIN %d %d %d", level, tile.X, tile.Y)
 	}
 	return ""
 }
 
-//Move action
-func (g *GameState) Move(unit *Unit, enemyHQ Point) string {
-	if unit.ID == -1 {
-		return ""
-	}
-	var target *Tile
-	// debug("unit:%#v\n", unit)
-	until := func(c Point) bool {
-		if c == unit.Point {
-			return false
-		}
-		target = g.Map[c]
-		// debug("\ttarget: %#v\n", target)
-		if target.OccupiedBy == nil {
-			if target.Owner != ME || (target.Owner == ME && !target.active) {
-				return true
-			}
-			return false
-		}
-		if u, ok := target.OccupiedBy.(Unit); ok {
-			// debug("\tbyUnit:%#v\n", target.OccupiedBy)
-			if unit.Level == 3 {
-				return true
-			}
-			if u.Level < unit.Level {
-				return true
-			}
-			return false
-		}
-		if b, ok := target.OccupiedBy.(Building); ok {
-			if b.Owner == ME {
-				return false
-			}
-			if b.BuildingType != TOWER {
-				return true
-			}
-			if b.BuildingType == TOWER && unit.Level == 3 {
-				return true
-			}
-			return false
-		}
-		if target.DefendedBy == nil || target.DefendedBy != nil && unit.Level == 3 {
-			return true
-		}
-		return false
-	}
-	g.Map.BFS(unit.Point, until)
-
-	if until(target.Point) {
-		g.Map[unit.Point].OccupiedBy = nil
-		g.Map[target.Point].OccupiedBy = *unit
-		g.Map[target.Point].Owner = ME
-		g.Map[target.Point].active = true
-		return fmt.Sprintf("MOVE %d %d %d", unit.ID, target.X, target.Y)
-	}
-	return ""
-}
-
 //Build action
-func (g *GameState) Build(tile *Tile, myHQ Point) string {
+func (g *GameState) Build(tile *Tile) string {
 	if tile.MineSpot {
 		if tile.OccupiedBy == nil {
-			if g.Gold > g.mineCost() {
+			if g.Economy().ShouldBuildMine(g.mineCost()) {
 				g.Gold -= g.mineCost()
-				g.Income += 4
+				g.Income += MineIncome
 				building := &Building{tile.Point, ME, MINE}
-				g.Map[tile.Point].OccupiedBy = building
+				g.Buildings[MINE] = append(g.Buildings[MINE], building)
+				g.Map.At(tile.Point).OccupiedBy = *building
 				return fmt.Sprintf("BUILD MINE %d %d", tile.X, tile.Y)
 			}
 		}
 	}
-	if tile.distance(myHQ) == 2 {
-		if tile.OccupiedBy == nil && !tile.MineSpot {
-			if tile.Owner == ME {
-				if g.Gold > 15 {
-					//g.Gold -= 15
-					//building := &Building{tile.Point, ME, TOWER}
-					//g.Map[tile.Point].OccupiedBy = building
-					//return fmt.Sprintf("BUILD TOWER %d %d", tile.X, tile.Y)
-				}
-			}
-		}
-	}
 	return ""
 }
 
+//BuildTower builds a tower on an empty tile of our active territory that is not a mine spot
+func (g *GameState) BuildTower(tile *Tile) string {
+	if tile.Owner != ME || !tile.active || tile.OccupiedBy != nil || tile.MineSpot || g.Gold < TowerCost {
+		return ""
+	}
+	g.Gold -= TowerCost
+	building := &Building{tile.Point, ME, TOWER}
+	g.Buildings[TOWER] = append(g.Buildings[TOWER], building)
+	tile.OccupiedBy = *building
+	return fmt.Sprintf("BUILD TOWER %d %d", tile.X, tile.Y)
+}
+
 func (g GameState) String() string {
 	return fmt.Sprintf("Gold:%d, Income:%d, EnemyGold:%d,EnemyIncome: %d", g.Gold, g.Income, g.EnemyGold, g.EnemyIncome)
 }
@@ -457,22 +635,32 @@ func (g GameState) String() string {
 //Turn actions
 func (g *GameState) Turn(turn int) []string {
 	var actions []string
+	if g.Deadline.IsZero() && !fixedBudget {
+		g.Deadline = time.Now().Add(TurnBudget)
+	}
 	debug("turn:%d, %s\n", turn, g)
+	if lethal := g.Lethal(); lethal != nil {
+		return lethal
+	}
+	if book, ok := g.Opening(turn); ok {
+		return append(book, "WAIT")
+	}
 	myHQ := g.Buildings[HQ][0]
 
 	enemyHQ := g.EnemyBuildings[HQ][0]
 	// tilesFromEnemy := g.Map.TilesSortedByDistanceFrom(enemyHQ.Point)
 
-	for _, unit := range g.Units {
-		action := g.Move(unit, enemyHQ.Point)
-		if action != "" {
-			actions = append(actions, action)
-		}
+	actions = append(actions, g.MoveUnits(enemyHQ.Point)...)
+	actions = append(actions, g.DefendRush()...)
+	if plan := g.PlanCut(); plan != nil {
+		debug("CUT: %v score:%d cost:%d\n", plan.Tiles, plan.Score, plan.Cost)
+		actions = append(actions, g.ApplyCut(plan)...)
 	}
+	actions = append(actions, g.Defend(g.Threats())...)
 	tilesFromPlayer := g.Map.TilesSortedByDistanceFrom(myHQ.Point)
 	for _, tile := range tilesFromPlayer {
 		if tile.Owner == ME && tile.active {
-			action := g.Build(tile, myHQ.Point)
+			action := g.Build(tile)
 			if action != "" {
 				actions = append(actions, action)
 			}
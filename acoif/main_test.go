@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+//walledBoard has a void wall cutting off the top right corner from both HQs
+var walledBoard = []string{
+	"OOO.......#X",
+	"OOO.......##",
+	"OOO.........",
+	"....#.......",
+	"....#.......",
+	"....#....#..",
+	"..#....#....",
+	".......#....",
+	".......#....",
+	".........XXX",
+	".........XXX",
+	".........XXX",
+}
+
+var walledEntities = []string{"0 0 0 0", "1 0 11 11"}
+
+func TestByDistanceStrictWeakOrdering(t *testing.T) {
+	gs := parseBoard(t, walledBoard, walledEntities, nil)
+	for _, from := range []Point{P(0, 0), P(11, 11), P(5, 5)} {
+		b := ByDistance{gs.Map.BFS(from, func(c Point) bool { return false }), gs.Map.Tiles()}
+		n := b.Len()
+		for i := 0; i < n; i++ {
+			if b.Less(i, i) {
+				t.Fatalf("from %s: Less(%s, %s) is not irreflexive", from, b.tiles[i], b.tiles[i])
+			}
+			for j := 0; j < n; j++ {
+				if i != j && b.Less(i, j) == b.Less(j, i) {
+					t.Fatalf("from %s: %s and %s are not strictly ordered", from, b.tiles[i], b.tiles[j])
+				}
+				if !b.Less(i, j) {
+					continue
+				}
+				for k := 0; k < n; k++ {
+					if b.Less(j, k) && !b.Less(i, k) {
+						t.Fatalf("from %s: Less is not transitive for %s, %s, %s", from, b.tiles[i], b.tiles[j], b.tiles[k])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTilesSortedByDistanceFrom(t *testing.T) {
+	gs := parseBoard(t, walledBoard, walledEntities, nil)
+	from := P(0, 0)
+	tiles := gs.Map.TilesSortedByDistanceFrom(from)
+	distance := gs.Map.BFS(from, func(c Point) bool { return false })
+	if tiles[0].Point != from {
+		t.Errorf("first tile %s, want the start %s", tiles[0], from)
+	}
+	last := 0
+	for _, tile := range tiles {
+		d, ok := distance[tile.Point]
+		if !ok {
+			d = Width * Height
+		}
+		if d < last {
+			t.Fatalf("%s at distance %d comes after distance %d", tile, d, last)
+		}
+		last = d
+	}
+	if corner := tiles[len(tiles)-1].Point; corner != P(11, 0) {
+		t.Errorf("last tile %s, want the unreachable corner", corner)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	gs := parseBoard(t, walledBoard, walledEntities, nil)
+	from := P(11, 11)
+	distance := gs.Map.BFS(from, func(c Point) bool { return false })
+	layers := gs.Map.Layers(from)
+	count := 0
+	for d, layer := range layers {
+		if len(layer) == 0 {
+			t.Errorf("layer %d is empty", d)
+		}
+		for i, tile := range layer {
+			if distance[tile.Point] != d {
+				t.Errorf("%s at distance %d in layer %d", tile, distance[tile.Point], d)
+			}
+			if i > 0 && (tile.Y < layer[i-1].Y || tile.Y == layer[i-1].Y && tile.X < layer[i-1].X) {
+				t.Errorf("layer %d: %s after %s", d, tile, layer[i-1])
+			}
+		}
+		count += len(layer)
+	}
+	if count != len(distance) {
+		t.Errorf("layers hold %d tiles, %d are reachable", count, len(distance))
+	}
+}
+
+func TestTilesSortedByDistanceFromMatchesByDistance(t *testing.T) {
+	gs := parseBoard(t, walledBoard, walledEntities, nil)
+	for _, from := range []Point{P(0, 0), P(11, 11), P(5, 5)} {
+		want := gs.Map.Tiles()
+		sort.Sort(ByDistance{gs.Map.BFS(from, func(c Point) bool { return false }), want})
+		if got := gs.Map.TilesSortedByDistanceFrom(from); !reflect.DeepEqual(got, want) {
+			t.Errorf("from %s: layer order differs from ByDistance", from)
+		}
+	}
+}
+
+func TestTilesSortedByDistanceFromDeterministic(t *testing.T) {
+	order := func() []Point {
+		gs := parseBoard(t, walledBoard, walledEntities, nil)
+		points := make([]Point, 0, Width*Height)
+		for _, tile := range gs.Map.TilesSortedByDistanceFrom(P(11, 11)) {
+			points = append(points, tile.Point)
+		}
+		return points
+	}
+	want := order()
+	for i := 0; i < 20; i++ {
+		if got := order(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("parse %d sorted the tiles differently:\n%v\n%v", i, got, want)
+		}
+	}
+}
+
+func TestPointDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b Point
+		want int
+	}{
+		{P(0, 0), P(0, 0), 0},
+		{P(0, 0), P(3, 4), 7},
+		{P(3, 4), P(0, 0), 7},
+		{P(1, 0), P(0, 1), 2},
+		{P(2, 3), P(3, 1), 3},
+		{P(11, 0), P(0, 11), 22},
+	} {
+		if got := c.a.distance(c.b); got != c.want {
+			t.Errorf("%s.distance(%s) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+//Unassigned is the assignment cost of an unreachable target
+const Unassigned = 1000000
+
+//PathBFS returns the distances and parents of a breadth first search over passable tiles
+func (m *TileMap) PathBFS(start Point, passable func(t *Tile) bool) (map[Point]int, map[Point]Point) {
+	distance := map[Point]int{start: 0}
+	parent := make(map[Point]Point)
+	frontier := []Point{start}
+	for len(frontier) > 0 {
+		var current Point
+		current, frontier = frontier[0], frontier[1:]
+		for _, next := range m.neighbours(current) {
+			if _, ok := distance[next.Point]; ok || !passable(next) {
+				continue
+			}
+			distance[next.Point] = distance[current] + 1
+			parent[next.Point] = current
+			frontier = append(frontier, next.Point)
+		}
+	}
+	return distance, parent
+}
+
+//Hungarian solves the rectangular assignment problem for len(cost) <= len(cost[0]),
+//it returns the column assigned to every row
+func Hungarian(cost [][]int) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+	u := make([]int, n+1)
+	v := make([]int, m+1)
+	match := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]int, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.MaxInt32
+		}
+		for match[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := match[j0], math.MaxInt32, 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if c := cost[i0-1][j-1] - u[i0] - v[j]; c < minv[j] {
+					minv[j], way[j] = c, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if match[j] != 0 {
+			assignment[match[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
+
+type unitRoute struct {
+	unit   *Unit
+	parent map[Point]Point
+	target Point
+}
+
+//step returns the first tile on the route to the target
+func (r unitRoute) step() Point {
+	p := r.target
+	for r.parent[p] != r.unit.Point {
+		p = r.parent[p]
+	}
+	return p
+}
+
+//MoveUnits assigns distinct frontier targets to our units and moves them one step each
+func (g *GameState) MoveUnits(enemyHQ Point) []string {
+	fromEnemy := g.Map.BFS(enemyHQ, func(c Point) bool { return false })
+	targets := make([]Point, 0)
+	for _, p := range g.Map.SortedPoints() {
+		tile := g.Map.At(p)
+		if (tile.Owner != ME || !tile.active) && g.Map.RequiredLevel(ME, p) < Impossible {
+			targets = append(targets, p)
+		}
+	}
+	routes := make([]unitRoute, 0)
+	cost := make([][]int, 0)
+	for _, unit := range g.Units {
+		if unit.ID == -1 {
+			continue
+		}
+		level := unit.Level
+		distance, parent := g.Map.PathBFS(unit.Point, func(t *Tile) bool {
+			if _, ok := t.OccupiedBy.(Unit); ok && t.Owner == ME {
+				return true
+			}
+			return g.Map.RequiredLevel(ME, t.Point) <= level
+		})
+		row := make([]int, len(targets)+len(g.Units))
+		for j, p := range targets {
+			d, ok := distance[p]
+			if !ok || d > params.MaxMoveDistance || g.Map.RequiredLevel(ME, p) > level {
+				row[j] = Unassigned
+				continue
+			}
+			row[j] = d*params.StepCost + fromEnemy[p]
+			if g.Map.At(p).Owner == ENEMY {
+				row[j] -= params.EnemyTileBonus
+			}
+		}
+		for j := len(targets); j < len(row); j++ {
+			row[j] = Unassigned
+		}
+		routes = append(routes, unitRoute{unit, parent, unit.Point})
+		cost = append(cost, row)
+	}
+	for i, j := range Hungarian(cost) {
+		if cost[i][j] < Unassigned {
+			routes[i].target = targets[j]
+		}
+	}
+	//units stepping onto a tile of another unit wait until it moved away
+	actions := make([]string, 0)
+	pending := routes
+	for len(pending) > 0 {
+		waiting := make([]unitRoute, 0)
+		for _, r := range pending {
+			if r.target == r.unit.Point {
+				continue
+			}
+			next := g.Map.At(r.step())
+			if u, ok := next.OccupiedBy.(Unit); ok && u.Owner == ME {
+				waiting = append(waiting, r)
+				continue
+			}
+			if g.Map.RequiredLevel(ME, next.Point) > r.unit.Level {
+				continue
+			}
+			actions = append(actions, g.moveUnit(r.unit, next))
+		}
+		if len(waiting) == len(pending) {
+			break
+		}
+		pending = waiting
+	}
+	return actions
+}
+
+//moveUnit moves a unit onto a neighbouring tile
+func (g *GameState) moveUnit(unit *Unit, tile *Tile) string {
+	g.Map.At(unit.Point).OccupiedBy = nil
+	unit.Point = tile.Point
+	if tile.Owner != ME || !tile.active {
+		g.Income++
+	}
+	tile.OccupiedBy = *unit
+	tile.Owner = ME
+	tile.active = true
+	g.disconnectEnemy()
+	return fmt.Sprintf("MOVE %d %d %d", unit.ID, tile.X, tile.Y)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//OpeningRadius bounds the region around our HQ an opening book entry describes
+const OpeningRadius = 6
+
+//go:embed openings.txt
+var openingData string
+
+var openingBook = mustParseOpeningBook(openingData)
+
+//Opening is a scripted sequence of commands for the first turns
+type Opening struct {
+	Name  string
+	Mines []Point //nil matches any layout
+	Void  []Point //nil matches any map shape
+	Turns map[int][]scriptCommand
+}
+
+//scriptCommand is a checked opening book command, args are level x y for TRAIN, x1 y1 x2 y2 for
+//MOVE and the building type x y for BUILD
+type scriptCommand struct {
+	text string
+	verb string
+	args []int
+}
+
+//OpeningBook is a list of openings, earlier entries take precedence
+type OpeningBook []*Opening
+
+func inOpeningRegion(p Point) bool {
+	return Inside(p) && p.X+p.Y <= OpeningRadius
+}
+
+func parsePoints(fields []string) ([]Point, error) {
+	if len(fields) == 1 && fields[0] == "*" {
+		return nil, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("odd number of coordinates")
+	}
+	points := make([]Point, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		x, errX := strconv.Atoi(fields[i])
+		y, errY := strconv.Atoi(fields[i+1])
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid coordinate %s %s", fields[i], fields[i+1])
+		}
+		points = append(points, P(x, y))
+	}
+	sortPoints(points)
+	return points, nil
+}
+
+//parseScriptCommand checks the syntax, the level and the coordinates of a command
+func parseScriptCommand(command string) (scriptCommand, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return scriptCommand{}, fmt.Errorf("empty command")
+	}
+	c := scriptCommand{strings.Join(fields, " "), fields[0], make([]int, 0, 4)}
+	coordinates := fields[1:]
+	switch {
+	case c.verb == "TRAIN" && len(fields) == 4:
+		level, err := strconv.Atoi(fields[1])
+		if err != nil || level < 1 || level > 3 {
+			return scriptCommand{}, fmt.Errorf("invalid level in %q", c.text)
+		}
+		c.args = append(c.args, level)
+		coordinates = fields[2:]
+	case c.verb == "MOVE" && len(fields) == 5:
+	case c.verb == "BUILD" && len(fields) == 4 && (fields[1] == "MINE" || fields[1] == "TOWER"):
+		building := MINE
+		if fields[1] == "TOWER" {
+			building = TOWER
+		}
+		c.args = append(c.args, building)
+		coordinates = fields[2:]
+	default:
+		return scriptCommand{}, fmt.Errorf("invalid command %q", c.text)
+	}
+	for i := 0; i < len(coordinates); i += 2 {
+		x, errX := strconv.Atoi(coordinates[i])
+		y, errY := strconv.Atoi(coordinates[i+1])
+		if errX != nil || errY != nil || !Inside(P(x, y)) {
+			return scriptCommand{}, fmt.Errorf("invalid coordinate %s %s in %q", coordinates[i], coordinates[i+1], c.text)
+		}
+		c.args = append(c.args, x, y)
+	}
+	return c, nil
+}
+
+//ParseOpeningBook reads the opening book format described in openings.txt
+func ParseOpeningBook(data string) (OpeningBook, error) {
+	book := make(OpeningBook, 0)
+	var current *Opening
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if fields[0] != "opening" && current == nil {
+			return nil, fmt.Errorf("openings:%d: %s outside of an opening", n+1, fields[0])
+		}
+		var err error
+		switch fields[0] {
+		case "opening":
+			current = &Opening{Name: strings.Join(fields[1:], " "), Turns: make(map[int][]scriptCommand)}
+			book = append(book, current)
+		case "mines":
+			current.Mines, err = parsePoints(fields[1:])
+		case "void":
+			current.Void, err = parsePoints(fields[1:])
+		case "turn":
+			current.Turns, err = parseTurn(current.Turns, fields[1:])
+		default:
+			err = fmt.Errorf("unknown keyword %s", fields[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("openings:%d: %v", n+1, err)
+		}
+	}
+	return book, nil
+}
+
+//parseTurn adds the commands of a turn line to the script
+func parseTurn(turns map[int][]scriptCommand, fields []string) (map[int][]scriptCommand, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing commands")
+	}
+	turn, err := strconv.Atoi(fields[0])
+	if err != nil || turn < 0 {
+		return nil, fmt.Errorf("invalid turn %s", fields[0])
+	}
+	if _, ok := turns[turn]; ok {
+		return nil, fmt.Errorf("turn %d twice", turn)
+	}
+	script := make([]scriptCommand, 0)
+	for _, command := range strings.Split(strings.Join(fields[1:], " "), ";") {
+		c, err := parseScriptCommand(command)
+		if err != nil {
+			return nil, err
+		}
+		script = append(script, c)
+	}
+	turns[turn] = script
+	return turns, nil
+}
+
+func mustParseOpeningBook(data string) OpeningBook {
+	book, err := ParseOpeningBook(data)
+	if err != nil {
+		panic(err)
+	}
+	return book
+}
+
+func samePoints(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//Layout returns the mine spots and void tiles of the opening region of a normalised game state
+func (g *GameState) Layout() (mines []Point, void []Point) {
+	mines, void = make([]Point, 0), make([]Point, 0)
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			p := P(x, y)
+			if !inOpeningRegion(p) {
+				continue
+			}
+			if tile := g.Map.At(p); tile == nil {
+				void = append(void, p)
+			} else if tile.MineSpot {
+				mines = append(mines, p)
+			}
+		}
+	}
+	return mines, void
+}
+
+//Matches reports if the opening is written for the layout
+func (o *Opening) Matches(mines, void []Point) bool {
+	return (o.Mines == nil || samePoints(o.Mines, mines)) && (o.Void == nil || samePoints(o.Void, void))
+}
+
+//deviated reports if the enemy already reached the opening region
+func (g *GameState) deviated() bool {
+	for _, tile := range g.Map.Tiles() {
+		if tile.Owner == ENEMY && inOpeningRegion(tile.Point) {
+			return true
+		}
+	}
+	return false
+}
+
+//playScript applies scripted commands, an illegal command aborts the script
+func (g *GameState) playScript(script []scriptCommand) ([]string, error) {
+	actions := make([]string, 0, len(script))
+	for _, command := range script {
+		args := command.args
+		switch command.verb {
+		case "TRAIN":
+			p := P(args[1], args[2])
+			tile := g.Map.At(p)
+			if tile == nil || g.Map.RequiredLevel(ME, p) > args[0] || !g.trainable(tile) {
+				return nil, fmt.Errorf("can't train on %s", p)
+			}
+			action := g.TrainUnit(tile, args[0])
+			if action == "" {
+				return nil, fmt.Errorf("can't afford %s", command.text)
+			}
+			tile.Owner = ME
+			tile.active = true
+			actions = append(actions, action)
+		case "MOVE":
+			from, to := P(args[0], args[1]), P(args[2], args[3])
+			var unit *Unit
+			for _, u := range g.Units {
+				if u.Point == from && u.ID != -1 {
+					unit = u
+				}
+			}
+			tile := g.Map.At(to)
+			if unit == nil || tile == nil || from.distance(to) != 1 || g.Map.RequiredLevel(ME, to) > unit.Level {
+				return nil, fmt.Errorf("can't move from %s to %s", from, to)
+			}
+			actions = append(actions, g.moveUnit(unit, tile))
+		case "BUILD":
+			p := P(args[1], args[2])
+			tile := g.Map.At(p)
+			if tile == nil || tile.Owner != ME || !tile.active || tile.OccupiedBy != nil {
+				return nil, fmt.Errorf("can't build on %s", p)
+			}
+			action := ""
+			if args[0] == MINE && tile.MineSpot {
+				action = g.Build(tile)
+			} else if args[0] == TOWER {
+				action = g.BuildTower(tile)
+			}
+			if action == "" {
+				return nil, fmt.Errorf("can't build %s", command.text)
+			}
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+//trainable reports if the tile is our active territory or next to it
+func (g *GameState) trainable(tile *Tile) bool {
+	if tile.Owner == ME && tile.active {
+		return true
+	}
+	for _, next := range g.Map.neighbours(tile.Point) {
+		if next.Owner == ME && next.active {
+			return true
+		}
+	}
+	return false
+}
+
+//Opening plays the opening book on a normalised game state, false falls back to the regular logic
+func (g *GameState) Opening(turn int) ([]string, bool) {
+	if g.deviated() {
+		return nil, false
+	}
+	mines, void := g.Layout()
+	for _, o := range openingBook {
+		script, ok := o.Turns[turn]
+		if !ok || !o.Matches(mines, void) {
+			continue
+		}
+		c := g.Clone()
+		actions, err := c.playScript(script)
+		if err != nil {
+			debug("OPENING: %s turn %d: %v\n", o.Name, turn, err)
+			continue
+		}
+		debug("OPENING: %s turn %d\n", o.Name, turn)
+		*g = *c
+		return actions, true
+	}
+	return nil, false
+}
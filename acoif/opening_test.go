@@ -0,0 +1,212 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testBook = `
+opening mined
+mines 2 1
+void *
+turn 0 TRAIN 1 0 1
+
+opening walled
+mines *
+void 1 0
+turn 0 TRAIN 1 0 1;TRAIN 1 0 2
+
+opening east
+mines *
+void *
+turn 0 TRAIN 1 1 0
+
+opening south
+mines *
+void *
+turn 0 TRAIN 1 0 1
+`
+
+var openingRows = []string{
+	"O...........",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"...........X",
+}
+
+func openingBoard(t *testing.T, mines []Point, void []Point) *GameState {
+	rows := append([]string{}, openingRows...)
+	for _, p := range void {
+		row := []byte(rows[p.Y])
+		row[p.X] = '#'
+		rows[p.Y] = string(row)
+	}
+	gs := parseBoard(t, rows, []string{"0 0 0 0", "1 0 11 11"}, nil)
+	for _, p := range mines {
+		gs.Map.At(p).MineSpot = true
+	}
+	return gs
+}
+
+func withBook(t *testing.T, data string) {
+	book, err := ParseOpeningBook(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := openingBook
+	openingBook = book
+	t.Cleanup(func() { openingBook = saved })
+}
+
+func TestOpeningMatches(t *testing.T) {
+	book, err := ParseOpeningBook(testBook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name        string
+		mines, void []Point
+		want        []string
+	}{
+		{"mine layout", []Point{P(2, 1)}, []Point{}, []string{"mined", "east", "south"}},
+		{"other mine layout", []Point{P(1, 2)}, []Point{}, []string{"east", "south"}},
+		{"map shape", []Point{}, []Point{P(1, 0)}, []string{"walled", "east", "south"}},
+		{"open map", []Point{}, []Point{}, []string{"east", "south"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			matched := make([]string, 0)
+			for _, o := range book {
+				if o.Matches(c.mines, c.void) {
+					matched = append(matched, o.Name)
+				}
+			}
+			if !reflect.DeepEqual(matched, c.want) {
+				t.Errorf("matched %v, want %v", matched, c.want)
+			}
+		})
+	}
+}
+
+func TestOpeningChoosesEntry(t *testing.T) {
+	withBook(t, testBook)
+	quiet = true
+	defer func() { quiet = false }()
+	for _, c := range []struct {
+		name        string
+		mines, void []Point
+		want        []string
+	}{
+		{"layout specific entry first", []Point{P(2, 1)}, nil, []string{"TRAIN 1 0 1"}},
+		{"map shape entry", nil, []Point{P(1, 0)}, []string{"TRAIN 1 0 1", "TRAIN 1 0 2"}},
+		{"generic entry", nil, nil, []string{"TRAIN 1 1 0"}},
+		{"illegal entry falls through", []Point{P(2, 1)}, []Point{P(0, 1)}, []string{"TRAIN 1 1 0"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gs := openingBoard(t, c.mines, c.void)
+			if mines, void := gs.Layout(); !samePoints(mines, c.mines) || !samePoints(void, c.void) {
+				t.Fatalf("Layout() = %v %v, want %v %v", mines, void, c.mines, c.void)
+			}
+			actions, ok := gs.Opening(0)
+			if !ok || !reflect.DeepEqual(actions, c.want) {
+				t.Errorf("Opening(0) = %v %v, want %v", actions, ok, c.want)
+			}
+		})
+	}
+}
+
+func TestOpeningDeviation(t *testing.T) {
+	withBook(t, testBook)
+	gs := openingBoard(t, nil, nil)
+	gs.Map.At(P(3, 3)).Owner = ENEMY
+	if actions, ok := gs.Opening(0); ok {
+		t.Errorf("Opening(0) = %v after the enemy entered the opening region", actions)
+	}
+}
+
+func TestParseOpeningBookErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, line string
+	}{
+		{"trailing separator", "turn 0 TRAIN 1 1 0;"},
+		{"empty command", "turn 0 TRAIN 1 1 0;;MOVE 1 0 2 0"},
+		{"missing commands", "turn 0"},
+		{"negative turn", "turn -1 TRAIN 1 1 0"},
+		{"turn twice", "turn 0 TRAIN 1 1 0\nturn 0 TRAIN 1 0 1"},
+		{"unknown command", "turn 0 WAIT"},
+		{"invalid level", "turn 0 TRAIN 4 1 0"},
+		{"missing coordinate", "turn 0 MOVE 1 0 2"},
+		{"off the map", "turn 0 MOVE 11 0 12 0"},
+		{"unknown building", "turn 0 BUILD BARRACKS 1 0"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ParseOpeningBook("opening bad\n" + c.line); err == nil {
+				t.Errorf("ParseOpeningBook accepted %q", c.line)
+			}
+		})
+	}
+}
+
+func TestParseOpeningBookTurn(t *testing.T) {
+	book, err := ParseOpeningBook("opening spaced\nturn 12   TRAIN 1 1 2 ; MOVE 1 2 1 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []scriptCommand{{"TRAIN 1 1 2", "TRAIN", []int{1, 1, 2}}, {"MOVE 1 2 1 1", "MOVE", []int{1, 2, 1, 1}}}
+	if got := book[0].Turns[12]; !reflect.DeepEqual(got, want) {
+		t.Errorf("turn 12 = %v, want %v", got, want)
+	}
+}
+
+//playOpening plays the embedded book for the first turns, trained units get ids like the referee
+//gives them
+func playOpening(t *testing.T, gs *GameState, turns int) []string {
+	played := make([]string, 0)
+	id := 1
+	for turn := 0; turn < turns; turn++ {
+		actions, ok := gs.Opening(turn)
+		if !ok {
+			t.Fatalf("no opening on turn %d after %v", turn, played)
+		}
+		played = append(played, actions...)
+		for _, u := range gs.Units {
+			if u.ID == -1 {
+				u.ID = id
+				id++
+			}
+		}
+	}
+	return played
+}
+
+func TestOpeningBookLayouts(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+	for _, c := range []struct {
+		name string
+		void []Point
+		want []string
+	}{
+		{"east", nil, []string{"TRAIN 1 1 0", "MOVE 1 2 0", "MOVE 1 3 0", "MOVE 1 4 0",
+			"TRAIN 1 0 1", "MOVE 1 5 0", "MOVE 1 6 0", "MOVE 2 1 1", "MOVE 1 6 1", "MOVE 2 2 1"}},
+		{"east walled", []Point{P(3, 0)}, []string{"TRAIN 1 1 0", "MOVE 1 2 0", "MOVE 1 2 1", "MOVE 1 3 1",
+			"TRAIN 1 0 1", "MOVE 1 4 1", "MOVE 1 5 1", "MOVE 2 1 1", "MOVE 1 5 2", "MOVE 2 1 2"}},
+		{"south", []Point{P(1, 0)}, []string{"TRAIN 1 0 1", "MOVE 1 0 2", "MOVE 1 0 3", "MOVE 1 0 4",
+			"TRAIN 1 1 1", "MOVE 1 0 5", "MOVE 1 0 6", "MOVE 2 1 2", "MOVE 1 1 6", "MOVE 2 1 3"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gs := openingBoard(t, nil, c.void)
+			gs.Gold = 100
+			if got := playOpening(t, gs, 7); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("opening = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//ParseError locates malformed input
+type ParseError struct {
+	Line  int
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s: %v", e.Line, e.Field, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//InputReader reads the line based referee protocol
+type InputReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+//NewInputReader wraps a reader
+func NewInputReader(r io.Reader) *InputReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 1000000)
+	return &InputReader{scanner: scanner}
+}
+
+//NewInputReaderAt wraps a reader whose first line follows the given line of a longer stream,
+//errors then carry the line number within the stream
+func NewInputReaderAt(r io.Reader, line int) *InputReader {
+	in := NewInputReader(r)
+	in.line = line
+	return in
+}
+
+//Lines returns the number of the last line read
+func (in *InputReader) Lines() int {
+	return in.line
+}
+
+//Errorf creates a ParseError for the current line
+func (in *InputReader) Errorf(field, format string, a ...interface{}) error {
+	return &ParseError{in.line, field, fmt.Errorf(format, a...)}
+}
+
+//Line reads the next line, io.EOF at the end of the input
+func (in *InputReader) Line(field string) (string, error) {
+	if !in.scanner.Scan() {
+		if err := in.scanner.Err(); err != nil {
+			return "", &ParseError{in.line + 1, field, err}
+		}
+		return "", io.EOF
+	}
+	in.line++
+	return strings.TrimRight(in.scanner.Text(), "\r"), nil
+}
+
+//Next reads the next line, the end of the input is an error
+func (in *InputReader) Next(field string) (string, error) {
+	line, err := in.Line(field)
+	if err == io.EOF {
+		return "", &ParseError{in.line + 1, field, io.ErrUnexpectedEOF}
+	}
+	return line, err
+}
+
+//Ints reads a line of exactly n integers
+func (in *InputReader) Ints(field string, n int) ([]int, error) {
+	line, err := in.Next(field)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) != n {
+		return nil, in.Errorf(field, "expected %d values, got %d", n, len(fields))
+	}
+	values := make([]int, n)
+	for i, f := range fields {
+		if values[i], err = strconv.Atoi(f); err != nil {
+			return nil, in.Errorf(field, "invalid number %q", f)
+		}
+	}
+	return values, nil
+}
+
+//Int reads a line holding a single integer
+func (in *InputReader) Int(field string) (int, error) {
+	values, err := in.Ints(field, 1)
+	if err != nil {
+		return 0, err
+	}
+	return values[0], nil
+}
+
+//Count reads a non negative number of following entries
+func (in *InputReader) Count(field string) (int, error) {
+	n, err := in.Int(field)
+	if err == nil && (n < 0 || n > Width*Height) {
+		return 0, in.Errorf(field, "count %d out of range", n)
+	}
+	return n, err
+}
+
+//Point validates coordinates against the board
+func (in *InputReader) Point(field string, x, y int) (Point, error) {
+	p := P(x, y)
+	if !Inside(p) {
+		return p, in.Errorf(field, "%s is off the board", p)
+	}
+	return p, nil
+}
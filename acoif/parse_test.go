@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fixtureInputs(t testing.TB) []string {
+	rp, err := LoadReplayFile("testdata/replay.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := make([]string, 0, len(rp.Turns))
+	for _, turn := range rp.Turns {
+		inputs = append(inputs, turn.Input)
+	}
+	return inputs
+}
+
+//TestParseErrorStreamLine reads two turns from one stream, errors of the second turn count lines
+//from the start of the stream like errors of ReadTurnBlock
+func TestParseErrorStreamLine(t *testing.T) {
+	inputs := fixtureInputs(t)
+	lines := strings.Split(inputs[1], "\n")
+	lines[4+3] = "OO"
+	in := NewInputReader(strings.NewReader(inputs[0] + strings.Join(lines, "\n")))
+	if _, err := ReadTurnBlock(in); err != nil {
+		t.Fatal(err)
+	}
+	start := in.Lines()
+	block, err := ReadTurnBlock(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseGameState(NewInputReaderAt(strings.NewReader(block), start), nil)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseGameState error = %v, want a ParseError", err)
+	}
+	if want := strings.Count(inputs[0], "\n") + 4 + 3 + 1; pe.Line != want || pe.Field != "map" {
+		t.Errorf("error at line %d field %s, want line %d field map", pe.Line, pe.Field, want)
+	}
+}
+
+func TestReplayStateLine(t *testing.T) {
+	rp, err := LoadReplayFile("testdata/replay.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := rp.Turns[2]
+	turn.Input = strings.Replace(turn.Input, "\n", "\nbroken\n", 1)
+	rp.Turns[2] = turn
+	_, err = rp.State(2)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("State error = %v, want a ParseError", err)
+	}
+	if want := turn.Line + 2; pe.Line != want {
+		t.Errorf("error at line %d, want line %d of the replay file", pe.Line, want)
+	}
+}
+
+func FuzzParseGameState(f *testing.F) {
+	inputs := fixtureInputs(f)
+	for _, input := range inputs[:3] {
+		f.Add(input)
+	}
+	f.Add(inputs[len(inputs)-1])
+	f.Add("")
+	f.Add("20\n1\n20\n1\n")
+	f.Add(strings.Replace(inputs[0], "X", "#", -1))
+	f.Fuzz(func(t *testing.T, input string) {
+		gs, err := ParseGameState(NewInputReader(strings.NewReader(input)), nil)
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error %v is not a ParseError", err)
+			}
+			if lines := strings.Count(input, "\n") + 1; pe.Line < 1 || pe.Line > lines+1 {
+				t.Fatalf("error line %d outside of the %d input lines", pe.Line, lines)
+			}
+			return
+		}
+		if len(gs.Buildings[HQ]) != 1 || len(gs.EnemyBuildings[HQ]) != 1 {
+			t.Fatalf("parsed without one HQ per player")
+		}
+		for _, units := range [][]*Unit{gs.Units, gs.EnemyUnits} {
+			for _, u := range units {
+				if tile := gs.Map.At(u.Point); tile == nil || tile.OccupiedBy == nil {
+					t.Fatalf("unit %v is not on its tile", u)
+				}
+			}
+		}
+	})
+}
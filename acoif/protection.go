@@ -0,0 +1,96 @@
+package main
+
+//board is a map the protection rules are evaluated on, the bot's TileMap and the referee both
+//implement it so they can't disagree on what a unit may enter
+type board interface {
+	//cell returns the owner of a tile, if it is connected to its owner's HQ and the Unit or
+	//Building on it, ok is false for void tiles and tiles off the map
+	cell(p Point) (owner int, active bool, occupant interface{}, ok bool)
+}
+
+func (m *TileMap) cell(p Point) (int, bool, interface{}, bool) {
+	tile := m.At(p)
+	if tile == nil {
+		return UNOCCUPIED, false, nil, false
+	}
+	return tile.Owner, tile.active, tile.OccupiedBy, true
+}
+
+//protected reports if an active tower of the tile owner covers the tile. The HQ gives no
+//protection, its tile is covered like any other by an adjacent tower.
+func protected(b board, p Point) bool {
+	owner, active, _, ok := b.cell(p)
+	if !ok || owner == UNOCCUPIED || !active {
+		return false
+	}
+	for _, q := range []Point{p, p.add(dirs[0]), p.add(dirs[1]), p.add(dirs[2]), p.add(dirs[3])} {
+		towerOwner, towerActive, occupant, _ := b.cell(q)
+		if t, isBuilding := occupant.(Building); isBuilding && t.BuildingType == TOWER && t.Owner == owner && towerOwner == owner && towerActive {
+			return true
+		}
+	}
+	return false
+}
+
+//requiredLevel returns the unit level a player needs to train or move onto a tile, Impossible if it can't
+func requiredLevel(b board, player int, p Point) int {
+	owner, _, occupant, ok := b.cell(p)
+	if !ok {
+		return Impossible
+	}
+	if owner == player {
+		if occupant != nil {
+			return Impossible
+		}
+		return 1
+	}
+	level := 1
+	switch e := occupant.(type) {
+	case Unit:
+		level = e.Level + 1
+	case Building:
+		if e.BuildingType == TOWER {
+			level = 3
+		}
+	}
+	if protected(b, p) {
+		level = 3
+	}
+	if level > 3 {
+		level = 3
+	}
+	return level
+}
+
+//Protected reports if an active tower of the tile owner covers the tile
+func (m *TileMap) Protected(p Point) bool {
+	return protected(m, p)
+}
+
+//RequiredLevel returns the unit level a player needs to train or move onto a tile, Impossible if it can't
+func (m *TileMap) RequiredLevel(player int, p Point) int {
+	return requiredLevel(m, player, p)
+}
+
+//LevelMap holds the required level of every tile for one player, Impossible on void tiles
+type LevelMap [Height][Width]int
+
+//Levels computes the required levels of all tiles for a player, planners that query a map they
+//don't change many times use it instead of RequiredLevel. It is stale once the map changes.
+func (m *TileMap) Levels(player int) *LevelMap {
+	levels := new(LevelMap)
+	for y := range levels {
+		for x := range levels[y] {
+			levels[y][x] = m.RequiredLevel(player, P(x, y))
+		}
+	}
+	return levels
+}
+
+//At returns the required level of a point, Impossible off the board
+func (l *LevelMap) At(p Point) int {
+	if !Inside(p) {
+		return Impossible
+	}
+	return l[p.Y][p.X]
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//parseBoard builds a game state from map rows and building and unit lines in the turn input format
+func parseBoard(t *testing.T, rows, buildings, units []string) *GameState {
+	t.Helper()
+	input := fmt.Sprintf("20\n1\n20\n1\n%s\n%d\n", strings.Join(rows, "\n"), len(buildings))
+	for _, b := range buildings {
+		input += b + "\n"
+	}
+	input += fmt.Sprintf("%d\n", len(units))
+	for _, u := range units {
+		input += u + "\n"
+	}
+	gs, err := ParseGameState(NewInputReader(strings.NewReader(input)), nil)
+	if err != nil {
+		t.Fatalf("parse board: %v", err)
+	}
+	return gs
+}
+
+//protectionBoard has our territory on the left, active enemy territory on the right and an
+//inactive enemy strip in row 2
+var protectionBoard = struct {
+	rows, buildings, units []string
+}{
+	rows: []string{
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOxxxxxx",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+		"OOOOOOXXXXXX",
+	},
+	buildings: []string{
+		"0 0 0 0",   //our HQ
+		"1 0 11 11", //enemy HQ
+		"1 2 8 8",   //active enemy tower
+		"1 1 8 9",   //enemy mine next to the tower
+		"1 1 10 5",  //unprotected enemy mine
+		"1 2 9 2",   //enemy tower on an inactive tile
+		"0 2 1 5",   //our tower
+		"0 2 1 0",   //our tower next to our HQ
+	},
+	units: []string{
+		"1 7 3 8 6",  //level 3 enemy unit
+		"1 8 1 10 7", //level 1 enemy unit
+		"1 9 1 7 8",  //level 1 enemy unit next to the tower
+		"0 1 1 2 2",  //our unit
+	},
+}
+
+func TestRequiredLevel(t *testing.T) {
+	b := protectionBoard
+	gs := parseBoard(t, b.rows, b.buildings, b.units)
+	for _, c := range []struct {
+		name      string
+		player    int
+		p         Point
+		level     int
+		protected bool
+	}{
+		{"tower tile", ME, P(8, 8), 3, true},
+		{"tower neighbour", ME, P(8, 7), 3, true},
+		{"tower diagonal", ME, P(9, 9), 1, false},
+		{"building on a protected tile", ME, P(8, 9), 3, true},
+		{"unprotected building", ME, P(10, 5), 1, false},
+		{"inactive tower tile", ME, P(9, 2), 3, false},
+		{"inactive tower neighbour", ME, P(9, 3), 1, false},
+		{"level 3 unit", ME, P(8, 6), 3, false},
+		{"level 1 unit", ME, P(10, 7), 2, false},
+		{"unit on a protected tile", ME, P(7, 8), 3, true},
+		{"unprotected HQ", ME, P(11, 11), 1, false},
+		{"own empty tile", ME, P(3, 3), 1, false},
+		{"own unit", ME, P(2, 2), Impossible, false},
+		{"own HQ", ME, P(0, 0), Impossible, true},
+		{"enemy view of our tower", ENEMY, P(1, 5), 3, true},
+		{"enemy view of our tower neighbour", ENEMY, P(1, 4), 3, true},
+		{"enemy view of our unit", ENEMY, P(2, 2), 2, false},
+		{"enemy view of our HQ", ENEMY, P(0, 0), 3, true},
+		{"enemy view of our tower next to the HQ", ENEMY, P(1, 0), 3, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := gs.Map.RequiredLevel(c.player, c.p); got != c.level {
+				t.Errorf("RequiredLevel(%d, %s) = %d, want %d", c.player, c.p, got, c.level)
+			}
+			if got := gs.Map.Protected(c.p); got != c.protected {
+				t.Errorf("Protected(%s) = %v, want %v", c.p, got, c.protected)
+			}
+		})
+	}
+}
+
+func TestRequiredLevelVoid(t *testing.T) {
+	b := protectionBoard
+	rows := append([]string{}, b.rows...)
+	rows[4] = "OOOO#OXXXXXX"
+	gs := parseBoard(t, rows, b.buildings, b.units)
+	if got := gs.Map.RequiredLevel(ME, P(4, 4)); got != Impossible {
+		t.Errorf("RequiredLevel on void = %d, want Impossible", got)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	b := protectionBoard
+	rows := append([]string{}, b.rows...)
+	rows[4] = "OOOO#OXXXXXX"
+	gs := parseBoard(t, rows, b.buildings, b.units)
+	for _, player := range []int{ME, ENEMY} {
+		levels := gs.Map.Levels(player)
+		for y := -1; y <= Height; y++ {
+			for x := -1; x <= Width; x++ {
+				if got, want := levels.At(P(x, y)), gs.Map.RequiredLevel(player, P(x, y)); got != want {
+					t.Errorf("player %d: Levels().At(%d, %d) = %d, RequiredLevel = %d", player, x, y, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,543 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Referee constants
+const (
+	StartGold  = 10
+	TowerCost  = 15
+	MaxTurns   = 200
+	Impossible = 4
+	DRAW       = 2
+)
+
+//Strategy computes the actions of one bot turn
+type Strategy func(g *GameState, turn int) []string
+
+//Referee simulates a full match locally
+type Referee struct {
+	Void      map[Point]bool
+	Owner     map[Point]int
+	Mines     map[Point]Point
+	Buildings map[Point]*Building
+	Units     map[Point]*Unit
+	Gold      [2]int
+	Turn      int
+	Winner    int
+	active    [2]map[Point]bool
+	moved     map[int]bool
+	nextID    int
+}
+
+//HQPosition returns the headquarter spawn of a player
+func HQPosition(player int) Point {
+	if player == ME {
+		return P(0, 0)
+	}
+	return P(Width-1, Height-1)
+}
+
+//NewReferee creates a match from 12 map rows ('#' void, '.' land) and the mine spots
+func NewReferee(rows []string, mines map[Point]Point) *Referee {
+	r := &Referee{
+		Void:      make(map[Point]bool),
+		Owner:     make(map[Point]int),
+		Mines:     mines,
+		Buildings: make(map[Point]*Building),
+		Units:     make(map[Point]*Unit),
+		Gold:      [2]int{StartGold, StartGold},
+		Winner:    UNOCCUPIED,
+		moved:     make(map[int]bool),
+	}
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			p := P(x, y)
+			if y >= len(rows) || x >= len(rows[y]) || rows[y][x] == '#' {
+				r.Void[p] = true
+				continue
+			}
+			r.Owner[p] = UNOCCUPIED
+		}
+	}
+	for _, player := range []int{ME, ENEMY} {
+		hq := HQPosition(player)
+		delete(r.Void, hq)
+		r.Owner[hq] = player
+		r.Buildings[hq] = &Building{hq, player, HQ}
+	}
+	r.refresh()
+	return r
+}
+
+func (r *Referee) inside(p Point) bool {
+	_, ok := r.Owner[p]
+	return ok
+}
+
+func (r *Referee) refresh() {
+	for _, player := range []int{ME, ENEMY} {
+		active := make(map[Point]bool)
+		hq := HQPosition(player)
+		if b, ok := r.Buildings[hq]; ok && b.BuildingType == HQ && b.Owner == player {
+			frontier := []Point{hq}
+			active[hq] = true
+			for len(frontier) > 0 {
+				var current Point
+				current, frontier = frontier[0], frontier[1:]
+				for _, dir := range dirs {
+					next := current.add(dir)
+					if r.Owner[next] == player && r.inside(next) && !active[next] {
+						active[next] = true
+						frontier = append(frontier, next)
+					}
+				}
+			}
+		}
+		r.active[player] = active
+	}
+	for p, unit := range r.Units {
+		if !r.active[unit.Owner][p] {
+			delete(r.Units, p)
+		}
+	}
+}
+
+//Active reports if a tile is connected to the headquarter of its owner
+func (r *Referee) Active(p Point) bool {
+	owner, ok := r.Owner[p]
+	return ok && owner != UNOCCUPIED && r.active[owner][p]
+}
+
+func (r *Referee) cell(p Point) (int, bool, interface{}, bool) {
+	if !r.inside(p) {
+		return UNOCCUPIED, false, nil, false
+	}
+	var occupant interface{}
+	if u, ok := r.Units[p]; ok {
+		occupant = *u
+	} else if b, ok := r.Buildings[p]; ok {
+		occupant = *b
+	}
+	return r.Owner[p], r.Active(p), occupant, true
+}
+
+//Protected reports if an active tower covers the tile for its owner
+func (r *Referee) Protected(p Point) bool {
+	return protected(r, p)
+}
+
+//RequiredLevel returns the unit level a player needs to enter a tile, Impossible if it can't
+func (r *Referee) RequiredLevel(player int, p Point) int {
+	return requiredLevel(r, player, p)
+}
+
+//Income returns the gold a player earns at the start of the next turn
+func (r *Referee) Income(player int) int {
+	income := len(r.active[player])
+	for p, b := range r.Buildings {
+		if b.Owner == player && b.BuildingType == MINE && r.Active(p) {
+			income += 4
+		}
+	}
+	for _, u := range r.Units {
+		if u.Owner == player {
+			income -= unitPrices[u.Level].Upkeep
+		}
+	}
+	return income
+}
+
+func (r *Referee) mineCost(player int) int {
+	mines := 0
+	for _, b := range r.Buildings {
+		if b.Owner == player && b.BuildingType == MINE {
+			mines++
+		}
+	}
+	return 20 + 4*mines
+}
+
+//StartTurn pays the income of a player, bankrupt players lose all units
+func (r *Referee) StartTurn(player int) {
+	r.moved = make(map[int]bool)
+	r.Gold[player] += r.Income(player)
+	if r.Gold[player] < 0 {
+		r.Gold[player] = 0
+		for p, u := range r.Units {
+			if u.Owner == player {
+				delete(r.Units, p)
+			}
+		}
+	}
+}
+
+func (r *Referee) enter(unit *Unit, p Point) {
+	delete(r.Units, unit.Point)
+	if b, ok := r.Buildings[p]; ok && b.Owner != unit.Owner {
+		if b.BuildingType == HQ {
+			r.Winner = unit.Owner
+		}
+		delete(r.Buildings, p)
+	}
+	unit.Point = p
+	r.Units[p] = unit
+	r.Owner[p] = unit.Owner
+	r.refresh()
+}
+
+//Train a unit of a level on a tile
+func (r *Referee) Train(player, level int, p Point) error {
+	if level < 1 || level > 3 {
+		return fmt.Errorf("invalid level %d", level)
+	}
+	if r.Gold[player] < unitPrices[level].Train {
+		return fmt.Errorf("not enough gold to train level %d", level)
+	}
+	reachable := r.Owner[p] == player && r.Active(p)
+	for _, dir := range dirs {
+		next := p.add(dir)
+		if r.Owner[next] == player && r.Active(next) {
+			reachable = true
+		}
+	}
+	if !reachable {
+		return fmt.Errorf("%s is not adjacent to active territory", p)
+	}
+	if r.RequiredLevel(player, p) > level {
+		return fmt.Errorf("level %d can't enter %s", level, p)
+	}
+	r.Gold[player] -= unitPrices[level].Train
+	unit := &Unit{p, player, r.nextID, level}
+	r.nextID++
+	r.moved[unit.ID] = true
+	r.enter(unit, p)
+	return nil
+}
+
+//Move a unit one step towards its target
+func (r *Referee) Move(player, id int, target Point) error {
+	var unit *Unit
+	for _, u := range r.Units {
+		if u.ID == id && u.Owner == player {
+			unit = u
+		}
+	}
+	if unit == nil {
+		return fmt.Errorf("unknown unit %d", id)
+	}
+	if r.moved[id] {
+		return fmt.Errorf("unit %d can't move again", id)
+	}
+	if unit.Point == target || !r.inside(target) {
+		return nil
+	}
+	distance := make(map[Point]int)
+	distance[target] = 0
+	frontier := []Point{target}
+	for len(frontier) > 0 {
+		var current Point
+		current, frontier = frontier[0], frontier[1:]
+		for _, dir := range dirs {
+			next := current.add(dir)
+			if _, ok := distance[next]; !ok && r.inside(next) {
+				distance[next] = distance[current] + 1
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	best, bestDistance := unit.Point, distance[unit.Point]
+	for _, dir := range dirs {
+		next := unit.Point.add(dir)
+		if d, ok := distance[next]; ok && d < bestDistance && r.RequiredLevel(player, next) <= unit.Level {
+			best, bestDistance = next, d
+		}
+	}
+	if best == unit.Point {
+		return fmt.Errorf("unit %d is blocked", id)
+	}
+	r.moved[id] = true
+	r.enter(unit, best)
+	return nil
+}
+
+//Build a mine or a tower on a tile
+func (r *Referee) Build(player, buildingType int, p Point) error {
+	if r.Owner[p] != player || !r.Active(p) {
+		return fmt.Errorf("%s is not active territory", p)
+	}
+	if _, ok := r.Units[p]; ok {
+		return fmt.Errorf("%s is occupied", p)
+	}
+	if _, ok := r.Buildings[p]; ok {
+		return fmt.Errorf("%s is occupied", p)
+	}
+	_, mineSpot := r.Mines[p]
+	cost := TowerCost
+	if buildingType == MINE {
+		if !mineSpot {
+			return fmt.Errorf("%s is not a mine spot", p)
+		}
+		cost = r.mineCost(player)
+	} else if mineSpot {
+		return fmt.Errorf("can't build a tower on mine spot %s", p)
+	}
+	if r.Gold[player] < cost {
+		return fmt.Errorf("not enough gold to build on %s", p)
+	}
+	r.Gold[player] -= cost
+	r.Buildings[p] = &Building{p, player, buildingType}
+	r.refresh()
+	return nil
+}
+
+//Apply a single command of a player
+func (r *Referee) Apply(player int, command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := make([]int, 0, 3)
+	for _, f := range fields[1:] {
+		if n, err := strconv.Atoi(f); err == nil {
+			args = append(args, n)
+		}
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "WAIT", "MSG":
+		return nil
+	case "TRAIN":
+		if len(args) != 3 {
+			return fmt.Errorf("invalid command %q", command)
+		}
+		return r.Train(player, args[0], P(args[1], args[2]))
+	case "MOVE":
+		if len(args) != 3 {
+			return fmt.Errorf("invalid command %q", command)
+		}
+		return r.Move(player, args[0], P(args[1], args[2]))
+	case "BUILD":
+		if len(fields) != 4 || len(args) != 2 {
+			return fmt.Errorf("invalid command %q", command)
+		}
+		switch strings.ToUpper(fields[1]) {
+		case "MINE":
+			return r.Build(player, MINE, P(args[0], args[1]))
+		case "TOWER":
+			return r.Build(player, TOWER, P(args[0], args[1]))
+		}
+	}
+	return fmt.Errorf("invalid command %q", command)
+}
+
+//Play applies a full output line of a player, invalid commands are skipped
+func (r *Referee) Play(player int, line string) {
+	for _, command := range strings.Split(line, ";") {
+		if r.Over() {
+			return
+		}
+		if err := r.Apply(player, command); err != nil {
+			debug("referee: player %d: %v\n", player, err)
+		}
+	}
+}
+
+//Over reports if the match is decided
+func (r *Referee) Over() bool {
+	return r.Winner != UNOCCUPIED || r.Turn >= MaxTurns
+}
+
+//Result returns the winner, at the turn limit the larger active territory wins
+func (r *Referee) Result() int {
+	if r.Winner != UNOCCUPIED {
+		return r.Winner
+	}
+	mine, enemy := len(r.active[ME]), len(r.active[ENEMY])
+	if mine > enemy {
+		return ME
+	}
+	if enemy > mine {
+		return ENEMY
+	}
+	return DRAW
+}
+
+func relative(owner, player int) int {
+	if owner == UNOCCUPIED {
+		return owner
+	}
+	if owner == player {
+		return ME
+	}
+	return ENEMY
+}
+
+//InitInput is the initialization input given to both players
+func (r *Referee) InitInput() string {
+	spots := make([]Point, 0, len(r.Mines))
+	for p := range r.Mines {
+		spots = append(spots, p)
+	}
+	sort.Slice(spots, func(i, j int) bool {
+		return spots[i].Y < spots[j].Y || spots[i].Y == spots[j].Y && spots[i].X < spots[j].X
+	})
+	var sb strings.Builder
+	fmt.Fprintln(&sb, len(spots))
+	for _, p := range spots {
+		fmt.Fprintln(&sb, p.X, p.Y)
+	}
+	return sb.String()
+}
+
+//Input is the turn input as seen by a player
+func (r *Referee) Input(player int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, r.Gold[player])
+	fmt.Fprintln(&sb, r.Income(player))
+	fmt.Fprintln(&sb, r.Gold[1-player])
+	fmt.Fprintln(&sb, r.Income(1-player))
+	buildings := make([]*Building, 0)
+	units := make([]*Unit, 0)
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			p := P(x, y)
+			c := "."
+			switch relative(r.Owner[p], player) {
+			case ME:
+				c = "o"
+			case ENEMY:
+				c = "x"
+			}
+			if r.Void[p] {
+				c = "#"
+			} else if r.Active(p) {
+				c = strings.ToUpper(c)
+			}
+			sb.WriteString(c)
+			if b, ok := r.Buildings[p]; ok {
+				buildings = append(buildings, b)
+			}
+			if u, ok := r.Units[p]; ok {
+				units = append(units, u)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sort.SliceStable(buildings, func(i, j int) bool {
+		return relative(buildings[i].Owner, player) < relative(buildings[j].Owner, player)
+	})
+	fmt.Fprintln(&sb, len(buildings))
+	for _, b := range buildings {
+		fmt.Fprintln(&sb, relative(b.Owner, player), b.BuildingType, b.X, b.Y)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+	fmt.Fprintln(&sb, len(units))
+	for _, u := range units {
+		fmt.Fprintln(&sb, relative(u.Owner, player), u.ID, u.Level, u.X, u.Y)
+	}
+	return sb.String()
+}
+
+//runStrategy parses the referee input into a fresh GameState with the mine spots of the init
+//input and plays one turn, the player sends WAIT when the input doesn't parse or the bot panics
+func runStrategy(bot Strategy, mines map[Point]Point, input string, turn int) (actions []string) {
+	defer func() {
+		if err := recover(); err != nil {
+			debug("referee: bot crashed: %v\n", err)
+			actions = []string{"WAIT"}
+		}
+	}()
+	gs, err := ParseGameState(NewInputReader(strings.NewReader(input)), mines)
+	if err != nil {
+		debug("referee: turn %d: %v\n", turn, err)
+		return []string{"WAIT"}
+	}
+	return bot(gs, turn)
+}
+
+//PlayMatch runs two strategies against each other and returns the result
+func PlayMatch(r *Referee, bots [2]Strategy) int {
+	mines, err := ParseMineSpots(strings.NewReader(r.InitInput()))
+	if err != nil {
+		panic(err)
+	}
+	turns := [2]int{}
+	for !r.Over() {
+		player := r.Turn % 2
+		r.StartTurn(player)
+		actions := runStrategy(bots[player], mines, r.Input(player), turns[player])
+		r.Play(player, strings.Join(actions, ";"))
+		turns[player]++
+		r.Turn++
+	}
+	return r.Result()
+}
+
+//RandomMap generates a point symmetric map with mine spots
+func RandomMap(rng *rand.Rand) ([]string, map[Point]Point) {
+	for {
+		grid := make([][]byte, Height)
+		for y := range grid {
+			grid[y] = []byte(strings.Repeat(".", Width))
+		}
+		for y := 0; y < Height; y++ {
+			for x := 0; x < Width; x++ {
+				if P(x, y).distance(P(0, 0)) <= 2 || y*Width+x >= Width*Height/2 {
+					continue
+				}
+				if rng.Intn(100) < 15 {
+					grid[y][x] = '#'
+					grid[Height-1-y][Width-1-x] = '#'
+				}
+			}
+		}
+		rows := make([]string, Height)
+		for y := range grid {
+			rows[y] = string(grid[y])
+		}
+		r := NewReferee(rows, nil)
+		for p := range r.Owner {
+			r.Owner[p] = ME
+		}
+		r.refresh()
+		if len(r.active[ME]) < len(r.Owner) {
+			continue
+		}
+		mines := make(map[Point]Point)
+		for len(mines) < 8 {
+			p := P(rng.Intn(Width), rng.Intn(Height))
+			if grid[p.Y][p.X] == '#' || p.distance(P(0, 0)) <= 1 || p.distance(HQPosition(ENEMY)) <= 1 {
+				continue
+			}
+			q := P(Width-1-p.X, Height-1-p.Y)
+			mines[p] = p
+			mines[q] = q
+		}
+		return rows, mines
+	}
+}
+
+//SelfPlay runs local matches between two bots and returns the score of the first. Each map is
+//played twice with swapped sides, the player moving first wins most mirror matches.
+func SelfPlay(games int, bots [2]Strategy) Score {
+	rng := rand.New(rand.NewSource(1))
+	var score Score
+	var rows []string
+	var mines map[Point]Point
+	for i := 0; i < games; i++ {
+		side := i % 2
+		if side == 0 {
+			rows, mines = RandomMap(rng)
+		}
+		result := PlayMatch(NewReferee(rows, mines), [2]Strategy{bots[side], bots[1-side]})
+		score.Add(result, side)
+		fmt.Printf("game %d: bot 0 is player %d, result %d\n", i, side, result)
+	}
+	fmt.Printf("bot 0 vs bot 1: %s\n", score)
+	return score
+}
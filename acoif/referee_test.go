@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var openMap = strings.Split(strings.TrimSpace(strings.Repeat(strings.Repeat(".", Width)+"\n", Height)), "\n")
+
+//line returns the tiles from a to b along a row or a column
+func line(a, b Point) []Point {
+	points := []Point{a}
+	for p := a; p != b; points = append(points, p) {
+		switch {
+		case p.X < b.X:
+			p.X++
+		case p.X > b.X:
+			p.X--
+		case p.Y < b.Y:
+			p.Y++
+		default:
+			p.Y--
+		}
+	}
+	return points
+}
+
+//claim gives the tiles to a player
+func claim(r *Referee, player int, tiles ...[]Point) {
+	for _, list := range tiles {
+		for _, p := range list {
+			r.Owner[p] = player
+		}
+	}
+	r.refresh()
+}
+
+//place puts a unit of a player on a tile and returns its id
+func place(r *Referee, player, level int, p Point) int {
+	id := r.nextID
+	r.nextID++
+	r.Units[p] = &Unit{p, player, id, level}
+	return id
+}
+
+//enemyCorridor claims row 1 and column 11 for the enemy, so it touches our row 0 from below
+func enemyCorridor(r *Referee) {
+	claim(r, ENEMY, line(P(11, 11), P(11, 1)), line(P(11, 1), P(1, 1)))
+}
+
+func TestRefereeUpkeep(t *testing.T) {
+	for _, c := range []struct {
+		name            string
+		level, gold     int
+		wantGold, units int
+	}{
+		{"level 1 pays for itself", 1, 10, 13, 1},
+		{"level 3 upkeep", 3, 20, 4, 1},
+		{"bankrupt", 3, 10, 0, 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewReferee(openMap, nil)
+			claim(r, ME, line(P(0, 0), P(3, 0)))
+			place(r, ME, c.level, P(3, 0))
+			r.Gold[ME] = c.gold
+			if want := 4 - unitPrices[c.level].Upkeep; r.Income(ME) != want {
+				t.Errorf("income %d, want %d", r.Income(ME), want)
+			}
+			r.StartTurn(ME)
+			if r.Gold[ME] != c.wantGold || len(r.Units) != c.units {
+				t.Errorf("gold %d units %d, want gold %d units %d", r.Gold[ME], len(r.Units), c.wantGold, c.units)
+			}
+			if c.units == 0 && r.Income(ME) != 4 {
+				t.Errorf("income %d after bankruptcy, want the 4 tiles", r.Income(ME))
+			}
+		})
+	}
+}
+
+func TestRefereeCutsInactiveTerritory(t *testing.T) {
+	r := NewReferee(openMap, nil)
+	claim(r, ME, line(P(0, 0), P(3, 0)))
+	place(r, ME, 1, P(3, 0))
+	enemyCorridor(r)
+	r.Gold[ENEMY] = 10
+	if err := r.Train(ENEMY, 1, P(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []Point{P(2, 0), P(3, 0)} {
+		if r.Owner[p] != ME || r.Active(p) {
+			t.Errorf("%s: owner %d active %v, want our inactive tile", p, r.Owner[p], r.Active(p))
+		}
+	}
+	if _, ok := r.Units[P(3, 0)]; ok {
+		t.Errorf("unit on the cut off tile survived")
+	}
+	if income := r.Income(ME); income != 1 {
+		t.Errorf("income %d, want only the HQ tile", income)
+	}
+	if err := r.Train(ME, 1, P(3, 0)); err == nil {
+		t.Errorf("trained next to inactive territory only")
+	}
+}
+
+func TestRefereeTowerProtection(t *testing.T) {
+	r := NewReferee(openMap, nil)
+	claim(r, ME, line(P(0, 0), P(3, 0)))
+	enemyCorridor(r)
+	r.Gold = [2]int{TowerCost, 100}
+	if err := r.Build(ME, TOWER, P(2, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[Point]int{P(1, 0): 3, P(2, 0): 3, P(3, 0): 3, P(4, 0): 1} {
+		if got := r.RequiredLevel(ENEMY, p); got != want {
+			t.Errorf("RequiredLevel(ENEMY, %s) = %d, want %d", p, got, want)
+		}
+	}
+	if err := r.Train(ENEMY, 2, P(3, 0)); err == nil {
+		t.Errorf("level 2 entered a protected tile")
+	}
+	claim(r, UNOCCUPIED, []Point{P(1, 0)})
+	if got := r.RequiredLevel(ENEMY, P(3, 0)); got != 1 {
+		t.Errorf("inactive tower protects (3, 0): level %d", got)
+	}
+	if err := r.Train(ENEMY, 3, P(2, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Buildings[P(2, 0)]; ok {
+		t.Errorf("tower survived its capture")
+	}
+}
+
+func TestRefereeHQCapture(t *testing.T) {
+	r := NewReferee(openMap, nil)
+	claim(r, ME, line(P(0, 0), P(11, 0)), line(P(11, 0), P(11, 10)))
+	id := place(r, ME, 1, P(11, 10))
+	if r.Over() {
+		t.Fatal("match over before the capture")
+	}
+	r.Play(ME, fmt.Sprintf("MOVE %d 11 11;TRAIN 1 10 11", id))
+	if !r.Over() || r.Result() != ME {
+		t.Errorf("over %v result %d after moving onto the enemy HQ", r.Over(), r.Result())
+	}
+	if _, ok := r.Units[P(10, 11)]; ok {
+		t.Errorf("commands after the capture were played")
+	}
+}
+
+func TestRefereeTurnLimit(t *testing.T) {
+	r := NewReferee(openMap, nil)
+	r.Turn = MaxTurns
+	if !r.Over() || r.Result() != DRAW {
+		t.Errorf("over %v result %d with equal territories", r.Over(), r.Result())
+	}
+	claim(r, ENEMY, []Point{P(11, 10)})
+	if r.Result() != ENEMY {
+		t.Errorf("result %d, want the larger territory", r.Result())
+	}
+}
+
+func TestSelfPlaySwapsSides(t *testing.T) {
+	quiet, fixedBudget = true, true
+	defer func() { quiet, fixedBudget = false, false }()
+	bot := Normalised((*GameState).Turn)
+	score := SelfPlay(2, [2]Strategy{bot, bot})
+	if score.Games() != 2 || score.Wins != score.Losses {
+		t.Errorf("the same bot scores %s against itself", score)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//ANSI terminal colours
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiBlue  = "\x1b[34m"
+	ansiRed   = "\x1b[31m"
+)
+
+//SVG layout
+const (
+	svgCell   = 40
+	svgFooter = 30
+)
+
+//glyph returns the character drawn for a tile: unit level, H/M/T building, $ mine spot, o/x territory
+func (t *Tile) glyph() string {
+	switch e := t.OccupiedBy.(type) {
+	case Unit:
+		return fmt.Sprint(e.Level)
+	case Building:
+		return []string{"H", "M", "T"}[e.BuildingType]
+	}
+	if t.MineSpot {
+		return "$"
+	}
+	switch t.Owner {
+	case ME:
+		return "o"
+	case ENEMY:
+		return "x"
+	}
+	return "."
+}
+
+//Render draws the map with ANSI colours, ours in blue and the enemy in red, inactive tiles dimmed
+func (g *GameState) Render() string {
+	var sb strings.Builder
+	sb.WriteString("   ")
+	for x := 0; x < Width; x++ {
+		fmt.Fprintf(&sb, " %d", x%10)
+	}
+	sb.WriteString("\n")
+	for y := 0; y < Height; y++ {
+		fmt.Fprintf(&sb, "%2d ", y)
+		for x := 0; x < Width; x++ {
+			tile := g.Map.At(P(x, y))
+			if tile == nil {
+				sb.WriteString("  ")
+				continue
+			}
+			style := ""
+			switch tile.Owner {
+			case ME:
+				style = ansiBlue
+			case ENEMY:
+				style = ansiRed
+			}
+			if tile.Owner != UNOCCUPIED {
+				if tile.active {
+					style += ansiBold
+				} else {
+					style += ansiDim
+				}
+			}
+			fmt.Fprintf(&sb, " %s%s%s", style, tile.glyph(), ansiReset)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "%s\n", g)
+	return sb.String()
+}
+
+func svgFill(t *Tile) string {
+	switch {
+	case t.Owner == ME && t.active:
+		return "#4a90d9"
+	case t.Owner == ME:
+		return "#b9d3ee"
+	case t.Owner == ENEMY && t.active:
+		return "#d94a4a"
+	case t.Owner == ENEMY:
+		return "#eeb9b9"
+	}
+	return "#eeeeee"
+}
+
+//SVG draws the map as an SVG image with a caption below it
+func (g *GameState) SVG(caption string) string {
+	var sb strings.Builder
+	w, h := Width*svgCell, Height*svgCell+svgFooter
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace">`+"\n", w, h)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#333333"/>`+"\n", w, h)
+	for _, tile := range g.Map.Tiles() {
+		x, y := tile.X*svgCell, tile.Y*svgCell
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#999999"/>`+"\n", x, y, svgCell, svgCell, svgFill(tile))
+		if tile.MineSpot {
+			fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="none" stroke="#c9a227" stroke-width="3"/>`+"\n", x+svgCell/2, y+svgCell/2, svgCell/2-4)
+		}
+		if glyph := tile.glyph(); tile.OccupiedBy != nil {
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central" font-weight="bold">%s</text>`+"\n", x+svgCell/2, y+svgCell/2, svgCell/2, glyph)
+		}
+	}
+	fmt.Fprintf(&sb, `<text x="4" y="%d" font-size="12" fill="#ffffff">%s</text>`+"\n", Height*svgCell+svgFooter/2, html.EscapeString(caption))
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+//WriteSVG writes the SVG of a turn into a directory
+func (g *GameState) WriteSVG(dir string, turn int, caption string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("turn-%03d.svg", turn)), []byte(g.SVG(caption)), 0644)
+}
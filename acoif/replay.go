@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Replay file section headers
+const (
+	replayInit    = "INIT"
+	replayTurn    = "TURN"
+	replayActions = "ACTIONS"
+)
+
+//readLines reads n lines of input
+func readLines(in *InputReader, field string, n int) ([]string, error) {
+	lines := make([]string, 0, n)
+	for len(lines) < n {
+		line, err := in.Next(field)
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+//readCounted reads a count line followed by that many lines
+func readCounted(in *InputReader, field string) ([]string, error) {
+	head, err := in.Next(field)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(head))
+	if err != nil || count < 0 || count > Width*Height {
+		return nil, in.Errorf(field, "invalid count %q", head)
+	}
+	lines, err := readLines(in, field, count)
+	return append([]string{head}, lines...), err
+}
+
+//ReadInitBlock reads the raw mine spot input
+func ReadInitBlock(in *InputReader) (string, error) {
+	lines, err := readCounted(in, "numberMineSpots")
+	return strings.Join(lines, "\n") + "\n", err
+}
+
+//ReadTurnBlock reads the raw input of one turn, io.EOF if the input ended before it
+func ReadTurnBlock(in *InputReader) (string, error) {
+	first, err := in.Line("gold")
+	if err != nil {
+		return "", err
+	}
+	lines, err := readLines(in, "turn", 3+Height)
+	if err != nil {
+		return "", err
+	}
+	lines = append([]string{first}, lines...)
+	for _, field := range []string{"buildingCount", "unitCount"} {
+		counted, err := readCounted(in, field)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, counted...)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+//Recorder dumps the raw turn inputs and the emitted actions to a file
+type Recorder struct {
+	out io.WriteCloser
+}
+
+//NewRecorder creates the replay file and writes the initialization input
+func NewRecorder(path, init string) (*Recorder, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "%s\n%s", replayInit, init)
+	return &Recorder{out}, nil
+}
+
+//Record one turn, a nil recorder does nothing
+func (r *Recorder) Record(turn int, input, actions string) {
+	if r == nil {
+		return
+	}
+	fmt.Fprintf(r.out, "%s %d\n%s%s\n%s\n", replayTurn, turn, input, replayActions, actions)
+}
+
+//Close the replay file
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.out.Close()
+}
+
+//ReplayTurn is a recorded turn input with the actions the bot emitted
+type ReplayTurn struct {
+	Input   string
+	Actions string
+	Line    int //line of the replay file before the input
+}
+
+//Replay is a recorded match
+type Replay struct {
+	Init  string
+	Turns []ReplayTurn
+}
+
+//LoadReplay reads a file written by a Recorder
+func LoadReplay(r io.Reader) (*Replay, error) {
+	in := NewInputReader(r)
+	rp := &Replay{}
+	header, err := in.Line("header")
+	if err != nil || header != replayInit {
+		return nil, fmt.Errorf("replay: missing %s section", replayInit)
+	}
+	if rp.Init, err = ReadInitBlock(in); err != nil {
+		return nil, fmt.Errorf("replay: init: %v", err)
+	}
+	for {
+		header, err := in.Line("header")
+		if err == io.EOF {
+			return rp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header != fmt.Sprintf("%s %d", replayTurn, len(rp.Turns)) {
+			return nil, fmt.Errorf("replay: expected %s %d, got %q", replayTurn, len(rp.Turns), header)
+		}
+		line := in.Lines()
+		input, err := ReadTurnBlock(in)
+		if err != nil {
+			return nil, fmt.Errorf("replay: turn %d: %v", len(rp.Turns), err)
+		}
+		lines, err := readLines(in, "actions", 2)
+		if err != nil || lines[0] != replayActions {
+			return nil, fmt.Errorf("replay: turn %d: missing %s", len(rp.Turns), replayActions)
+		}
+		rp.Turns = append(rp.Turns, ReplayTurn{input, lines[1], line})
+	}
+}
+
+//LoadReplayFile reads a replay from disk
+func LoadReplayFile(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadReplay(f)
+}
+
+//Mines returns the recorded mine spots
+func (rp *Replay) Mines() (map[Point]Point, error) {
+	return ParseMineSpots(strings.NewReader(rp.Init))
+}
+
+//State reconstructs the game state of a recorded turn
+func (rp *Replay) State(turn int) (*GameState, error) {
+	mines, err := rp.Mines()
+	if err != nil {
+		return nil, fmt.Errorf("replay: init: %v", err)
+	}
+	t := rp.Turns[turn]
+	gs, err := ParseGameState(NewInputReaderAt(strings.NewReader(t.Input), t.Line), mines)
+	if err != nil {
+		return nil, fmt.Errorf("replay: turn %d: %w", turn, err)
+	}
+	return gs, nil
+}
+
+//Verify replays every turn and returns the turns whose actions differ from the recording
+func (rp *Replay) Verify(bot Strategy) []int {
+	diff := make([]int, 0)
+	for n, turn := range rp.Turns {
+		gs, err := rp.State(n)
+		if err != nil {
+			debug("%v\n", err)
+			diff = append(diff, n)
+			continue
+		}
+		if actions := strings.Join(bot(gs, n), ";"); actions != turn.Actions {
+			debug("replay: turn %d\n\trecorded: %s\n\tactual:   %s\n", n, turn.Actions, actions)
+			diff = append(diff, n)
+		}
+	}
+	return diff
+}
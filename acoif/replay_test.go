@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+//TestReplayFixture replays a recorded local match, a changed turn shows up as a diff
+func TestReplayFixture(t *testing.T) {
+	rp, err := LoadReplayFile("testdata/replay.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rp.Turns) == 0 {
+		t.Fatal("replay has no turns")
+	}
+	quiet, fixedBudget = true, true
+	defer func() { quiet, fixedBudget = false, false }()
+	if diff := rp.Verify(Normalised((*GameState).Turn)); len(diff) > 0 {
+		for _, n := range diff {
+			gs, err := rp.State(n)
+			if err != nil {
+				t.Errorf("turn %d: %v", n, err)
+				continue
+			}
+			t.Errorf("turn %d:\n\trecorded: %s\n\tactual:   %s", n, rp.Turns[n].Actions, strings.Join(Normalised((*GameState).Turn)(gs, n), ";"))
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+//Search limits
+const (
+	TurnBudget       = 40 * time.Millisecond
+	SearchMargin     = 4 * time.Millisecond //kept free of playouts for collector pauses
+	FixedPlayouts    = 200                  //playouts of a search without a deadline
+	MaxSearchActions = 12
+	WinScore         = 1000000
+)
+
+//fixedBudget stops the searches on their node limits instead of the clock, so tuning games replay
+//exactly whatever the machine load. Turns then take as long as the limits allow.
+var fixedBudget bool
+
+//budget returns the deadline of a search taking at most d of the turn deadline, zero without a
+//limit
+func (g *GameState) budget(d time.Duration) time.Time {
+	if fixedBudget {
+		return time.Time{}
+	}
+	deadline := time.Now().Add(d)
+	if !g.Deadline.IsZero() && g.Deadline.Before(deadline) {
+		deadline = g.Deadline
+	}
+	return deadline
+}
+
+//expired reports if a deadline passed, a zero deadline never does
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+//Action is a single TRAIN, MOVE or BUILD command
+type Action struct {
+	Command string
+	Value   int //level, unit id or building type
+	Point
+}
+
+func (a Action) String() string {
+	if a.Command == "BUILD" {
+		building := "MINE"
+		if a.Value == TOWER {
+			building = "TOWER"
+		}
+		return fmt.Sprintf("BUILD %s %d %d", building, a.X, a.Y)
+	}
+	return fmt.Sprintf("%s %d %d %d", a.Command, a.Value, a.X, a.Y)
+}
+
+//Clone deep copies the game state
+func (g *GameState) Clone() *GameState {
+	c := *g
+	for _, t := range g.Map.Tiles() {
+		tile := *t
+		c.Map.Set(&tile)
+	}
+	c.Units = cloneUnits(g.Units)
+	c.EnemyUnits = cloneUnits(g.EnemyUnits)
+	c.Buildings = cloneBuildings(g.Buildings)
+	c.EnemyBuildings = cloneBuildings(g.EnemyBuildings)
+	return &c
+}
+
+func cloneUnits(units []*Unit) []*Unit {
+	list := make([]*Unit, len(units))
+	for i, u := range units {
+		unit := *u
+		list[i] = &unit
+	}
+	return list
+}
+
+func cloneBuildings(buildings BuildingTypeMap) BuildingTypeMap {
+	m := make(BuildingTypeMap, len(buildings))
+	for t, list := range buildings {
+		m[t] = make([]*Building, len(list))
+		for i, b := range list {
+			building := *b
+			m[t][i] = &building
+		}
+	}
+	return m
+}
+
+//SearchState is a game state with the actions applied to it
+type SearchState struct {
+	*GameState
+	moved   map[int]bool
+	actions []Action
+}
+
+func newSearchState(g *GameState) *SearchState {
+	return &SearchState{g, make(map[int]bool), make([]Action, 0)}
+}
+
+func (s *SearchState) clone() *SearchState {
+	c := newSearchState(s.GameState.Clone())
+	for id := range s.moved {
+		c.moved[id] = true
+	}
+	c.actions = append(c.actions, s.actions...)
+	return c
+}
+
+//Actions generates the legal TRAIN, MOVE and BUILD commands
+func (s *SearchState) Actions() []Action {
+	actions := make([]Action, 0)
+	for _, unit := range s.Units {
+		if unit.ID == -1 || s.moved[unit.ID] {
+			continue
+		}
+		for _, next := range s.Map.neighbours(unit.Point) {
+			if s.Map.RequiredLevel(ME, next.Point) <= unit.Level {
+				actions = append(actions, Action{"MOVE", unit.ID, next.Point})
+			}
+		}
+	}
+	for _, p := range s.Map.SortedPoints() {
+		tile := s.Map.At(p)
+		if tile.Owner == ME && tile.active {
+			if tile.OccupiedBy == nil && tile.MineSpot && s.Economy().ShouldBuildMine(s.mineCost()) {
+				actions = append(actions, Action{"BUILD", MINE, p})
+			}
+			if tile.OccupiedBy == nil && !tile.MineSpot && s.Gold >= TowerCost {
+				actions = append(actions, Action{"BUILD", TOWER, p})
+			}
+			continue
+		}
+		level := s.Map.RequiredLevel(ME, p)
+		if level == Impossible || !s.Economy().CanTrain(level, 1) {
+			continue
+		}
+		for _, next := range s.Map.neighbours(p) {
+			if next.Owner == ME && next.active {
+				actions = append(actions, Action{"TRAIN", level, p})
+				break
+			}
+		}
+	}
+	return actions
+}
+
+//capture takes over a tile for us
+func (s *SearchState) capture(tile *Tile) {
+	tile.Owner = ME
+	tile.active = true
+	s.disconnectEnemy()
+}
+
+//Apply executes an action on the state
+func (s *SearchState) Apply(a Action) {
+	tile := s.Map.At(a.Point)
+	switch a.Command {
+	case "TRAIN":
+		s.TrainUnit(tile, a.Value)
+		s.capture(tile)
+	case "MOVE":
+		if tile.Owner != ME || !tile.active {
+			s.Income++
+		}
+		for _, unit := range s.Units {
+			if unit.ID == a.Value {
+				s.Map.At(unit.Point).OccupiedBy = nil
+				unit.Point = a.Point
+				tile.OccupiedBy = *unit
+			}
+		}
+		s.moved[a.Value] = true
+		s.capture(tile)
+	case "BUILD":
+		building := &Building{a.Point, ME, a.Value}
+		if a.Value == MINE {
+			s.Gold -= s.mineCost()
+			s.Income += MineIncome
+		} else {
+			s.Gold -= TowerCost
+		}
+		s.Buildings[a.Value] = append(s.Buildings[a.Value], building)
+		tile.OccupiedBy = *building
+	}
+	s.actions = append(s.actions, a)
+}
+
+//Evaluate scores the state from our point of view
+func (g *GameState) Evaluate() float64 {
+	if hqs := g.EnemyBuildings[HQ]; len(hqs) > 0 && g.Map.At(hqs[0].Point).Owner == ME {
+		return WinScore
+	}
+	score := float64(2*g.Income) + 0.5*float64(g.Gold)
+	if g.Income < 0 {
+		score -= 1000
+	}
+	for _, tile := range g.Map.Tiles() {
+		if !tile.active {
+			continue
+		}
+		sign := 1.0
+		if tile.Owner == ENEMY {
+			sign = -1.0
+		} else if tile.Owner != ME {
+			continue
+		}
+		score += sign * 10
+		if u, ok := tile.OccupiedBy.(Unit); ok {
+			score += sign * float64(unitPrices[u.Level].Train)
+		}
+	}
+	if threats := g.Threats(); len(threats) > 0 {
+		score -= float64(threats[0].Value)
+	}
+	return score
+}
+
+//Search runs randomised playouts until the deadline and returns the best action list found,
+//the fallback actions are kept unless the search finds a state scoring better than fallbackScore.
+//A step is only started while the slowest step so far still fits SearchMargin before the deadline,
+//without a deadline the search stops after FixedPlayouts.
+func (g *GameState) Search(deadline time.Time, rng *rand.Rand, fallback []string, fallbackScore float64) []string {
+	var best []Action
+	bestScore := fallbackScore
+	consider := func(s *SearchState) {
+		if score := s.Evaluate(); score > bestScore {
+			best, bestScore = append([]Action{}, s.actions...), score
+		}
+	}
+	var slowest time.Duration
+	fits := func(limit time.Time) bool {
+		return limit.IsZero() || time.Now().Add(slowest+SearchMargin).Before(limit)
+	}
+	timed := func(start time.Time) {
+		if d := time.Since(start); d > slowest {
+			slowest = d
+		}
+	}
+	greedy := newSearchState(g.Clone())
+	greedyDeadline := deadline
+	if !deadline.IsZero() {
+		greedyDeadline = time.Now().Add(time.Until(deadline) / 2)
+	}
+	for len(greedy.actions) < MaxSearchActions && fits(greedyDeadline) {
+		var next *SearchState
+		nextScore := greedy.Evaluate()
+		for _, a := range greedy.Actions() {
+			if !fits(greedyDeadline) {
+				break
+			}
+			start := time.Now()
+			c := greedy.clone()
+			c.Apply(a)
+			if score := c.Evaluate(); score > nextScore {
+				next, nextScore = c, score
+			}
+			timed(start)
+		}
+		if next == nil {
+			break
+		}
+		greedy = next
+		consider(greedy)
+	}
+	playouts := 0
+	for fits(deadline) && (!deadline.IsZero() || playouts < FixedPlayouts) {
+		s := newSearchState(g.Clone())
+		for len(s.actions) < MaxSearchActions && fits(deadline) {
+			start := time.Now()
+			actions := s.Actions()
+			if len(actions) == 0 || rng.Intn(MaxSearchActions) == 0 {
+				break
+			}
+			s.Apply(actions[rng.Intn(len(actions))])
+			consider(s)
+			timed(start)
+		}
+		playouts++
+	}
+	debug("SEARCH: playouts:%d score:%.1f fallback:%.1f actions:%v\n", playouts, bestScore, fallbackScore, best)
+	if best == nil {
+		return fallback
+	}
+	commands := make([]string, 0, len(best)+1)
+	for _, a := range best {
+		commands = append(commands, a.String())
+	}
+	return append(commands, "WAIT")
+}
+
+//SearchTurn tries to improve the greedy Turn within the turn budget, the greedy Turn gets the
+//first half of it
+func (g *GameState) SearchTurn(turn int) []string {
+	start := time.Now()
+	deadline := g.budget(TurnBudget)
+	greedy := g.Clone()
+	if !deadline.IsZero() {
+		greedy.Deadline = start.Add(deadline.Sub(start) / 2)
+	}
+	actions := greedy.Turn(turn)
+	g.Deadline = deadline
+	return g.Search(deadline, rand.New(rand.NewSource(int64(turn))), actions, greedy.Evaluate())
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//searchRows: our HQ with two tiles, the enemy HQ alone in its corner
+var searchRows = []string{
+	"OO..........",
+	"O...........",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"............",
+	"...........X",
+}
+
+func searchBoard(t *testing.T) *SearchState {
+	return newSearchState(parseBoard(t, searchRows, []string{"0 0 0 0", "1 0 11 11"}, []string{"0 1 1 1 0"}))
+}
+
+func commands(actions []Action) []string {
+	list := make([]string, len(actions))
+	for i, a := range actions {
+		list[i] = a.String()
+	}
+	return list
+}
+
+func TestSearchActions(t *testing.T) {
+	s := searchBoard(t)
+	want := []string{"MOVE 1 2 0", "MOVE 1 1 1", "TRAIN 1 2 0", "BUILD TOWER 0 1", "TRAIN 1 1 1", "TRAIN 1 0 2"}
+	if got := commands(s.Actions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Actions() = %v, want %v", got, want)
+	}
+	s.Gold = 9
+	want = []string{"MOVE 1 2 0", "MOVE 1 1 1"}
+	if got := commands(s.Actions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Actions() with 9 gold = %v, want %v", got, want)
+	}
+}
+
+func TestSearchApply(t *testing.T) {
+	s := searchBoard(t)
+	s.Apply(Action{"TRAIN", 1, P(2, 0)})
+	if tile := s.Map.At(P(2, 0)); s.Gold != 10 || s.Income != 1 || len(s.Units) != 2 || tile.Owner != ME || !tile.active || tile.OccupiedBy == nil {
+		t.Errorf("after TRAIN: gold %d income %d units %d tile %+v", s.Gold, s.Income, len(s.Units), tile)
+	}
+	s.Apply(Action{"MOVE", 1, P(1, 1)})
+	if s.Map.At(P(1, 0)).OccupiedBy != nil || s.Map.At(P(1, 1)).OccupiedBy == nil || s.Units[0].Point != P(1, 1) || s.Income != 2 {
+		t.Errorf("after MOVE: unit at %s income %d", s.Units[0].Point, s.Income)
+	}
+	for _, a := range s.Actions() {
+		if a.Command == "MOVE" && a.Value == 1 {
+			t.Errorf("unit 1 offered %s after it moved", a)
+		}
+	}
+	s.Apply(Action{"BUILD", TOWER, P(0, 1)})
+	if towers := s.Buildings[TOWER]; s.Gold != 10-TowerCost || len(towers) != 1 || towers[0].Point != P(0, 1) {
+		t.Errorf("after BUILD: gold %d towers %v", s.Gold, towers)
+	}
+	want := []string{"TRAIN 1 2 0", "MOVE 1 1 1", "BUILD TOWER 0 1"}
+	if got := commands(s.actions); !reflect.DeepEqual(got, want) {
+		t.Errorf("actions %v, want %v", got, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		change func(s *SearchState)
+		want   float64
+	}{
+		//2 * income + gold / 2 + 10 per active tile and the training cost of units, enemy ones negative
+		{"start", func(s *SearchState) {}, 2 + 10 + 30 + 10 - 10},
+		{"one more tile", func(s *SearchState) { s.Apply(Action{"MOVE", 1, P(1, 1)}) }, 4 + 10 + 40 + 10 - 10},
+		{"bankrupt", func(s *SearchState) { s.Income = -1 }, -2 + 10 + 30 + 10 - 10 - 1000},
+		{"enemy HQ captured", func(s *SearchState) { s.Map.At(P(11, 11)).Owner = ME }, WinScore},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s := searchBoard(t)
+			c.change(s)
+			if got := s.Evaluate(); got != c.want {
+				t.Errorf("Evaluate() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateThreat(t *testing.T) {
+	gs := parseBoard(t, chainRows, defenceBuildings, nil)
+	safe := gs.Clone()
+	safe.EnemyGold = 0
+	if lost := safe.Evaluate() - gs.Evaluate(); lost != 6 {
+		t.Errorf("the threat to (4, 0) costs %v, want its value 6", lost)
+	}
+}
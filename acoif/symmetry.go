@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Transform maps between board coordinates and the normalised board where our HQ is at (0, 0)
+type Transform struct {
+	Mirrored bool
+}
+
+//Apply maps a point, the transform is its own inverse
+func (t Transform) Apply(p Point) Point {
+	if !t.Mirrored {
+		return p
+	}
+	return P(Width-1-p.X, Height-1-p.Y)
+}
+
+//Command maps the coordinates of a TRAIN, MOVE or BUILD command
+func (t Transform) Command(command string) string {
+	fields := strings.Fields(command)
+	if !t.Mirrored || len(fields) != 4 {
+		return command
+	}
+	switch fields[0] {
+	case "TRAIN", "MOVE", "BUILD":
+	default:
+		return command
+	}
+	x, errX := strconv.Atoi(fields[2])
+	y, errY := strconv.Atoi(fields[3])
+	if errX != nil || errY != nil {
+		return command
+	}
+	p := t.Apply(P(x, y))
+	return fmt.Sprintf("%s %s %d %d", fields[0], fields[1], p.X, p.Y)
+}
+
+//Commands maps a list of commands
+func (t Transform) Commands(commands []string) []string {
+	mapped := make([]string, len(commands))
+	for i, command := range commands {
+		mapped[i] = t.Command(command)
+	}
+	return mapped
+}
+
+func (t Transform) entity(e Entity) Entity {
+	switch v := e.(type) {
+	case Unit:
+		v.Point = t.Apply(v.Point)
+		return v
+	case Building:
+		v.Point = t.Apply(v.Point)
+		return v
+	}
+	return e
+}
+
+//Normalise mirrors the game state in place so our HQ is at (0, 0)
+func (g *GameState) Normalise() Transform {
+	hqs := g.Buildings[HQ]
+	t := Transform{len(hqs) > 0 && hqs[0].X >= Width/2}
+	if !t.Mirrored {
+		return t
+	}
+	var m TileMap
+	for _, tile := range g.Map.Tiles() {
+		tile.Point = t.Apply(tile.Point)
+		tile.OccupiedBy = t.entity(tile.OccupiedBy)
+		m.Set(tile)
+	}
+	g.Map = m
+	for _, units := range [][]*Unit{g.Units, g.EnemyUnits} {
+		for _, u := range units {
+			u.Point = t.Apply(u.Point)
+		}
+	}
+	for _, buildings := range []BuildingTypeMap{g.Buildings, g.EnemyBuildings} {
+		for _, list := range buildings {
+			for _, b := range list {
+				b.Point = t.Apply(b.Point)
+			}
+		}
+	}
+	enemyPos = t.Apply(enemyPos)
+	return t
+}
+
+//Normalised runs a strategy on the normalised board and maps its commands back
+func Normalised(bot Strategy) Strategy {
+	return func(g *GameState, turn int) []string {
+		t := g.Normalise()
+		return t.Commands(bot(g, turn))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTransformApply(t *testing.T) {
+	mirror := Transform{true}
+	for p, want := range map[Point]Point{P(0, 0): P(11, 11), P(11, 11): P(0, 0), P(3, 5): P(8, 6), P(11, 0): P(0, 11)} {
+		if got := mirror.Apply(p); got != want {
+			t.Errorf("Apply(%s) = %s, want %s", p, got, want)
+		}
+		if got := (Transform{}).Apply(p); got != p {
+			t.Errorf("identity Apply(%s) = %s", p, got)
+		}
+	}
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			if p := P(x, y); mirror.Apply(mirror.Apply(p)) != p {
+				t.Fatalf("mirroring %s twice gives %s", p, mirror.Apply(mirror.Apply(p)))
+			}
+		}
+	}
+}
+
+func TestTransformCommand(t *testing.T) {
+	for _, c := range []struct {
+		command, mirrored string
+	}{
+		{"TRAIN 1 2 3", "TRAIN 1 9 8"},
+		{"MOVE 4 0 0", "MOVE 4 11 11"},
+		{"BUILD MINE 1 0", "BUILD MINE 10 11"},
+		{"BUILD TOWER 5 6", "BUILD TOWER 6 5"},
+		{"WAIT", "WAIT"},
+		{"MSG 1 2 3", "MSG 1 2 3"},
+		{"MSG TRAIN 1 2 3", "MSG TRAIN 1 2 3"},
+		{"MOVE 4 x 0", "MOVE 4 x 0"},
+	} {
+		if got := (Transform{true}).Command(c.command); got != c.mirrored {
+			t.Errorf("mirrored Command(%q) = %q, want %q", c.command, got, c.mirrored)
+		}
+		if got := (Transform{}).Command(c.command); got != c.command {
+			t.Errorf("identity Command(%q) = %q", c.command, got)
+		}
+	}
+}
+
+//symmetryBoard: our territory in the top left with a unit and a mine spot, the enemy in the
+//bottom right
+var symmetryBoard = struct {
+	rows, buildings, units []string
+	mine                   Point
+}{
+	rows: []string{
+		"OOOO........",
+		"OOO.........",
+		"OO..........",
+		"O.....#.....",
+		"............",
+		"............",
+		"............",
+		"............",
+		".....#......",
+		"..........XX",
+		".........XXX",
+		"........XXXX",
+	},
+	buildings: []string{"0 0 0 0", "1 0 11 11"},
+	units:     []string{"0 1 1 3 0", "1 2 1 10 10"},
+	mine:      P(1, 1),
+}
+
+//mirrorBoard turns the board around so our HQ is at (11, 11)
+func mirrorBoard(rows, buildings, units []string) ([]string, []string, []string) {
+	mirror := Transform{true}
+	mirrored := make([]string, len(rows))
+	for y, row := range rows {
+		b := []byte(row)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		mirrored[len(rows)-1-y] = string(b)
+	}
+	var bs, us []string
+	for _, b := range buildings {
+		var owner, kind, x, y int
+		fmt.Sscan(b, &owner, &kind, &x, &y)
+		p := mirror.Apply(P(x, y))
+		bs = append(bs, fmt.Sprintf("%d %d %d %d", owner, kind, p.X, p.Y))
+	}
+	for _, u := range units {
+		var owner, id, level, x, y int
+		fmt.Sscan(u, &owner, &id, &level, &x, &y)
+		p := mirror.Apply(P(x, y))
+		us = append(us, fmt.Sprintf("%d %d %d %d %d", owner, id, level, p.X, p.Y))
+	}
+	return mirrored, bs, us
+}
+
+func TestNormalisedMirroredBoard(t *testing.T) {
+	quiet, fixedBudget = true, true
+	defer func() { quiet, fixedBudget = false, false }()
+	b := symmetryBoard
+	bot := Normalised((*GameState).Turn)
+
+	gs := parseBoard(t, b.rows, b.buildings, b.units)
+	gs.Gold = 100
+	gs.Map.At(b.mine).MineSpot = true
+	want := Transform{true}.Commands(bot(gs, 30))
+
+	rows, buildings, units := mirrorBoard(b.rows, b.buildings, b.units)
+	mirrored := parseBoard(t, rows, buildings, units)
+	mirrored.Gold = 100
+	mirrored.Map.At(Transform{true}.Apply(b.mine)).MineSpot = true
+	got := bot(mirrored, 30)
+
+	kinds := make(map[string]bool)
+	for _, command := range want {
+		kinds[command[:4]] = true
+	}
+	if !kinds["TRAI"] || !kinds["MOVE"] || !kinds["BUIL"] {
+		t.Fatalf("the board gives %v, want TRAIN, MOVE and BUILD commands", want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mirrored board gives\n%v\nwant the mirrored commands\n%v", got, want)
+	}
+}
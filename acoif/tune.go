@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+//Parameters are the heuristic weights of the greedy bot
+type Parameters struct {
+	TrainHorizon    int //turns a new unit must not bankrupt us
+	MineHorizon     int //turns a mine has to pay back within
+	StepCost        int //move assignment cost of one step
+	EnemyTileBonus  int //move assignment bonus for enemy tiles
+	MaxMoveDistance int //farthest target a unit is assigned to
+	MineValue       int //weight of a mine in cuts and threats
+	TowerValue      int //weight of a tower in cuts and threats
+}
+
+//DefaultParameters are the hand tuned weights, paste tuner results here
+var DefaultParameters = Parameters{
+	TrainHorizon:    20,
+	MineHorizon:     20,
+	StepCost:        10,
+	EnemyTileBonus:  5,
+	MaxMoveDistance: 24,
+	MineValue:       20,
+	TowerValue:      15,
+}
+
+//params are the weights of the strategy currently running
+var params = DefaultParameters
+
+//quiet silences debug output while tuning
+var quiet bool
+
+//Tunable bounds a parameter and sets its perturbation size
+type Tunable struct {
+	Name     string
+	Min, Max int
+	Step     int
+}
+
+var tunables = []Tunable{
+	{"TrainHorizon", 1, 60, 4},
+	{"MineHorizon", 1, 60, 4},
+	{"StepCost", 1, 40, 2},
+	{"EnemyTileBonus", 0, 40, 2},
+	{"MaxMoveDistance", 1, Width + Height, 2},
+	{"MineValue", 0, 60, 4},
+	{"TowerValue", 0, 60, 4},
+}
+
+//TuneRate scales the SPSA update
+const TuneRate = 2.0
+
+//values returns the parameters in the order of tunables
+func (p *Parameters) values() []*int {
+	return []*int{&p.TrainHorizon, &p.MineHorizon, &p.StepCost, &p.EnemyTileBonus, &p.MaxMoveDistance, &p.MineValue, &p.TowerValue}
+}
+
+func (p Parameters) String() string {
+	fields := make([]string, len(tunables))
+	for i, v := range p.values() {
+		fields[i] = fmt.Sprintf("%s:%d", tunables[i].Name, *v)
+	}
+	return strings.Join(fields, " ")
+}
+
+//withVector returns the parameters rounded and clamped from a real valued vector
+func withVector(theta []float64) Parameters {
+	p := DefaultParameters
+	for i, v := range p.values() {
+		*v = int(math.Round(math.Max(float64(tunables[i].Min), math.Min(float64(tunables[i].Max), theta[i]))))
+	}
+	return p
+}
+
+//WithParameters runs a strategy with its own heuristic weights
+func WithParameters(p Parameters, bot Strategy) Strategy {
+	return func(g *GameState, turn int) []string {
+		saved := params
+		params = p
+		defer func() { params = saved }()
+		return bot(g, turn)
+	}
+}
+
+//Score is the outcome of a batch of games from the point of view of one side
+type Score struct {
+	Wins, Losses, Draws int
+}
+
+//Games returns the number of games played
+func (s Score) Games() int {
+	return s.Wins + s.Losses + s.Draws
+}
+
+//WinRate returns the mean score, a draw counts half, and its 95% confidence interval
+func (s Score) WinRate() (rate, interval float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0.5, 0.5
+	}
+	rate = (float64(s.Wins) + float64(s.Draws)/2) / n
+	variance := (float64(s.Wins)*(1-rate)*(1-rate) + float64(s.Draws)*(0.5-rate)*(0.5-rate) + float64(s.Losses)*rate*rate) / n
+	return rate, 1.96 * math.Sqrt(variance/n)
+}
+
+//Add counts the result of a match the scored side played as player side
+func (s *Score) Add(result, side int) {
+	switch {
+	case result == DRAW:
+		s.Draws++
+	case result == side:
+		s.Wins++
+	default:
+		s.Losses++
+	}
+}
+
+func (s Score) String() string {
+	rate, interval := s.WinRate()
+	return fmt.Sprintf("+%d -%d =%d win rate %.1f%% ± %.1f%%", s.Wins, s.Losses, s.Draws, 100*rate, 100*interval)
+}
+
+//Compare plays pairs of games with swapped sides on random maps and scores a against b
+func Compare(a, b Parameters, pairs int, rng *rand.Rand) Score {
+	bot := Normalised((*GameState).Turn)
+	bots := []Strategy{WithParameters(a, bot), WithParameters(b, bot)}
+	var score Score
+	for i := 0; i < pairs; i++ {
+		rows, mines := RandomMap(rng)
+		for side := 0; side < 2; side++ {
+			score.Add(PlayMatch(NewReferee(rows, mines), [2]Strategy{bots[side], bots[1-side]}), side)
+		}
+	}
+	return score
+}
+
+//Tune runs SPSA on the greedy bot's parameters, every iteration plays pairs game pairs
+//between two opposite perturbations and moves towards the winner. The games run with a fixed
+//budget so a run repeats exactly.
+func Tune(iterations, pairs int) Parameters {
+	quiet, fixedBudget = true, true
+	defer func() { quiet, fixedBudget = false, false }()
+	rng := rand.New(rand.NewSource(1))
+	theta := make([]float64, len(tunables))
+	for i, v := range DefaultParameters.values() {
+		theta[i] = float64(*v)
+	}
+	report := func(iteration int) {
+		p := withVector(theta)
+		fmt.Printf("iteration %d: %s\n\tvs default: %s\n", iteration, p, Compare(p, DefaultParameters, pairs, rng))
+	}
+	for k := 1; k <= iterations; k++ {
+		delta := make([]float64, len(tunables))
+		plus, minus := make([]float64, len(tunables)), make([]float64, len(tunables))
+		for i, t := range tunables {
+			delta[i] = float64(2*rng.Intn(2) - 1)
+			plus[i] = theta[i] + delta[i]*float64(t.Step)
+			minus[i] = theta[i] - delta[i]*float64(t.Step)
+		}
+		score := Compare(withVector(plus), withVector(minus), pairs, rng)
+		rate, _ := score.WinRate()
+		gain := TuneRate / math.Pow(float64(k), 0.602)
+		for i, t := range tunables {
+			theta[i] += gain * (rate - 0.5) * delta[i] * float64(t.Step)
+			theta[i] = math.Max(float64(t.Min), math.Min(float64(t.Max), theta[i]))
+		}
+		fmt.Printf("iteration %d: plus vs minus %s\n", k, score)
+		if k%10 == 0 && k != iterations {
+			report(k)
+		}
+	}
+	report(iterations)
+	return withVector(theta)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestWinRate(t *testing.T) {
+	for _, c := range []struct {
+		score          Score
+		rate, interval float64
+	}{
+		{Score{}, 0.5, 0.5},
+		{Score{Wins: 10}, 1, 0},
+		{Score{Draws: 4}, 0.5, 0},
+		{Score{Wins: 5, Losses: 5}, 0.5, 1.96 * math.Sqrt(0.25/10)},
+		{Score{Wins: 3, Losses: 1}, 0.75, 1.96 * math.Sqrt(0.1875/4)},
+		{Score{Wins: 1, Losses: 1, Draws: 2}, 0.5, 1.96 * math.Sqrt(0.125/4)},
+	} {
+		rate, interval := c.score.WinRate()
+		if math.Abs(rate-c.rate) > 1e-9 || math.Abs(interval-c.interval) > 1e-9 {
+			t.Errorf("%+v: WinRate() = %v ± %v, want %v ± %v", c.score, rate, interval, c.rate, c.interval)
+		}
+	}
+}
+
+func TestScoreAdd(t *testing.T) {
+	var s Score
+	for _, m := range [][2]int{{ME, ME}, {ENEMY, ENEMY}, {ENEMY, ME}, {ME, ENEMY}, {DRAW, ME}} {
+		s.Add(m[0], m[1])
+	}
+	if want := (Score{Wins: 2, Losses: 2, Draws: 1}); s != want {
+		t.Errorf("score %+v, want %+v", s, want)
+	}
+}
+
+func TestWithVector(t *testing.T) {
+	theta := make([]float64, len(tunables))
+	for i, v := range DefaultParameters.values() {
+		theta[i] = float64(*v)
+	}
+	if got := withVector(theta); got != DefaultParameters {
+		t.Errorf("withVector(defaults) = %s", got)
+	}
+	theta[0] = -5    //TrainHorizon below its minimum 1
+	theta[1] = 1000  //MineHorizon above its maximum 60
+	theta[2] = 12.5  //StepCost rounds half away from zero
+	theta[3] = 7.49  //EnemyTileBonus rounds down
+	theta[4] = 30    //MaxMoveDistance above Width + Height
+	theta[5] = 24.51 //MineValue rounds up
+	want := DefaultParameters
+	want.TrainHorizon, want.MineHorizon, want.StepCost, want.EnemyTileBonus, want.MaxMoveDistance, want.MineValue = 1, 60, 13, 7, Width+Height, 25
+	if got := withVector(theta); got != want {
+		t.Errorf("withVector() = %s, want %s", got, want)
+	}
+}
+
+func TestFixedBudgetMatchRepeats(t *testing.T) {
+	quiet, fixedBudget = true, true
+	defer func() { quiet, fixedBudget = false, false }()
+	rows, mines := RandomMap(rand.New(rand.NewSource(5)))
+	play := func() *Referee {
+		r := NewReferee(rows, mines)
+		bot := Normalised((*GameState).Turn)
+		PlayMatch(r, [2]Strategy{WithParameters(DefaultParameters, bot), bot})
+		return r
+	}
+	first, second := play(), play()
+	if first.Turn != second.Turn || first.Gold != second.Gold || !reflect.DeepEqual(first.Owner, second.Owner) {
+		t.Errorf("the same match ended differently: turn %d and %d, gold %v and %v", first.Turn, second.Turn, first.Gold, second.Gold)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Action kinds
+const (
+	ActionWait = iota
+	ActionMove
+	ActionDig
+	ActionRequest
+)
+
+//Action is the command of one robot, the message is shown next to the robot in the viewer
+type Action struct {
+	Kind    int
+	Robot   int
+	Target  Point
+	Item    int
+	Message string
+}
+
+//Wait keeps a robot in place
+func Wait(robot int, message string) Action {
+	return Action{ActionWait, robot, Point{}, Nothing, message}
+}
+
+//Move sends a robot towards a cell
+func Move(robot int, p Point, message string) Action {
+	return Action{ActionMove, robot, p, Nothing, message}
+}
+
+//Dig digs a cell, the robot moves next to it first
+func Dig(robot int, p Point, message string) Action {
+	return Action{ActionDig, robot, p, Nothing, message}
+}
+
+//Request asks the headquarters for a radar or a trap
+func Request(robot int, item int, message string) Action {
+	return Action{ActionRequest, robot, Point{}, item, message}
+}
+
+//String serialises the action to the protocol
+func (a Action) String() string {
+	var command string
+	switch a.Kind {
+	case ActionMove:
+		command = fmt.Sprintf("MOVE %d %d", a.Target.X, a.Target.Y)
+	case ActionDig:
+		command = fmt.Sprintf("DIG %d %d", a.Target.X, a.Target.Y)
+	case ActionRequest:
+		command = "REQUEST TRAP"
+		if a.Item == Radar {
+			command = "REQUEST RADAR"
+		}
+	default:
+		command = "WAIT"
+	}
+	if a.Message != "" {
+		command += " " + a.Message
+	}
+	return command
+}
+
+//ParseAction reads a protocol line of a robot
+func ParseAction(robot int, line string) (Action, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Action{}, fmt.Errorf("robot %d: empty command", robot)
+	}
+	message := func(n int) string {
+		if len(fields) <= n {
+			return ""
+		}
+		return strings.Join(fields[n:], " ")
+	}
+	switch fields[0] {
+	case "WAIT":
+		return Wait(robot, message(1)), nil
+	case "MOVE", "DIG":
+		if len(fields) < 3 {
+			return Action{}, fmt.Errorf("robot %d: %s needs coordinates", robot, fields[0])
+		}
+		x, errX := strconv.Atoi(fields[1])
+		y, errY := strconv.Atoi(fields[2])
+		if errX != nil || errY != nil {
+			return Action{}, fmt.Errorf("robot %d: invalid coordinates %q %q", robot, fields[1], fields[2])
+		}
+		if fields[0] == "MOVE" {
+			return Move(robot, Point{x, y}, message(3)), nil
+		}
+		return Dig(robot, Point{x, y}, message(3)), nil
+	case "REQUEST":
+		if len(fields) >= 2 && fields[1] == "RADAR" {
+			return Request(robot, Radar, message(2)), nil
+		}
+		if len(fields) >= 2 && fields[1] == "TRAP" {
+			return Request(robot, Trap, message(2)), nil
+		}
+		return Action{}, fmt.Errorf("robot %d: unknown item in %q", robot, line)
+	}
+	return Action{}, fmt.Errorf("robot %d: unknown command %q", robot, line)
+}
+
+//DigsNow reports if the robot is next to the dig target, otherwise it moves this turn
+func (a Action) DigsNow(robot Entity) bool {
+	return a.Kind == ActionDig && !robot.Destroyed() && robot.Distance(a.Target) <= 1
+}
+
+//Validate checks the action against the map size and the robot
+func (a Action) Validate(o GameObject, robot Entity) error {
+	if a.Robot != robot.ID {
+		return fmt.Errorf("robot %d: action for robot %d", robot.ID, a.Robot)
+	}
+	if robot.Destroyed() && a.Kind != ActionWait {
+		return fmt.Errorf("robot %d: destroyed robots can only wait", robot.ID)
+	}
+	switch a.Kind {
+	case ActionMove, ActionDig:
+		if a.Target.X < 0 || a.Target.Y < 0 || a.Target.X >= o.Width || a.Target.Y >= o.Height {
+			return fmt.Errorf("robot %d: %v is off the map", robot.ID, a.Target)
+		}
+		if a.Kind == ActionDig && a.Target.X == 0 {
+			return fmt.Errorf("robot %d: can't dig the headquarters at %v", robot.ID, a.Target)
+		}
+	case ActionRequest:
+		if a.Item != Radar && a.Item != Trap {
+			return fmt.Errorf("robot %d: can't request item %d", robot.ID, a.Item)
+		}
+		if robot.X != 0 {
+			return fmt.Errorf("robot %d: requests need the headquarters, robot is at %v", robot.ID, robot.Point)
+		}
+	}
+	return nil
+}
+
+//ValidateTurn checks one action per robot, invalid moves and digs are clamped onto the map and
+//everything else that is invalid waits
+func (o GameObject) ValidateTurn(ti *TurnInput, actions []Action) []Action {
+	robots := ti.PlayerRobots()
+	valid := make([]Action, len(robots))
+	for i, robot := range robots {
+		if i >= len(actions) {
+			valid[i] = Wait(robot.ID, "missing")
+			Debug("robot %d: missing action\n", robot.ID)
+			continue
+		}
+		a := actions[i]
+		err := a.Validate(o, robot)
+		if err == nil {
+			valid[i] = a
+			continue
+		}
+		Debug("%v\n", err)
+		fixed := a
+		fixed.Robot = robot.ID
+		fixed.Target = a.Target.Clamp(&o)
+		if fixed.Kind == ActionDig && fixed.Target.X == 0 {
+			fixed.Kind = ActionMove
+		}
+		if fixed.Validate(o, robot) != nil {
+			fixed = Wait(robot.ID, a.Message)
+		}
+		valid[i] = fixed
+	}
+	return valid
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	for _, a := range []Action{
+		Wait(1, ""),
+		Wait(1, "ID(1) wait"),
+		Move(2, Point{0, 4}, "ID(2) return"),
+		Move(2, Point{-3, 40}, ""),
+		Dig(3, Point{29, 14}, "ID(3) dig"),
+		Dig(3, Point{1, 0}, ""),
+		Request(4, Radar, ""),
+		Request(5, Trap, "ID(5) request-trap"),
+	} {
+		line := a.String()
+		b, err := ParseAction(a.Robot, line)
+		if err != nil {
+			t.Errorf("ParseAction(%q): %v", line, err)
+			continue
+		}
+		if b != a {
+			t.Errorf("ParseAction(%q) = %+v, want %+v", line, b, a)
+		}
+	}
+}
+
+func TestActionString(t *testing.T) {
+	for _, c := range []struct {
+		a    Action
+		line string
+	}{
+		{Wait(1, ""), "WAIT"},
+		{Move(1, Point{0, 7}, "ID(1) return"), "MOVE 0 7 ID(1) return"},
+		{Dig(1, Point{12, 3}, ""), "DIG 12 3"},
+		{Request(1, Radar, ""), "REQUEST RADAR"},
+		{Request(1, Trap, "x"), "REQUEST TRAP x"},
+	} {
+		if got := c.a.String(); got != c.line {
+			t.Errorf("%+v.String() = %q, want %q", c.a, got, c.line)
+		}
+	}
+}
+
+func TestParseActionErrors(t *testing.T) {
+	for _, line := range []string{"", "   ", "MOVE 1", "DIG a 2", "DIG 1 2.5", "REQUEST", "REQUEST ORE", "JUMP 1 2"} {
+		if a, err := ParseAction(0, line); err == nil {
+			t.Errorf("ParseAction(%q) = %+v, want an error", line, a)
+		}
+	}
+}
+
+var validationGame = GameObject{Width: 30, Height: 15}
+
+func TestValidate(t *testing.T) {
+	atHQ := Entity{Point{0, 3}, 0, MyRobot, Nothing}
+	field := Entity{Point{5, 5}, 1, MyRobot, Nothing}
+	destroyed := Entity{Point{Nothing, Nothing}, 2, MyRobot, Nothing}
+	for _, c := range []struct {
+		name  string
+		a     Action
+		robot Entity
+		valid bool
+	}{
+		{"move on the map", Move(1, Point{29, 14}, ""), field, true},
+		{"move off the map", Move(1, Point{30, 5}, ""), field, false},
+		{"negative move", Move(1, Point{3, -1}, ""), field, false},
+		{"dig on the map", Dig(1, Point{5, 6}, ""), field, true},
+		{"dig off the map", Dig(1, Point{5, 15}, ""), field, false},
+		{"dig at the headquarters", Dig(1, Point{0, 5}, ""), field, false},
+		{"request at the headquarters", Request(0, Radar, ""), atHQ, true},
+		{"request away from the headquarters", Request(1, Trap, ""), field, false},
+		{"request of an unknown item", Request(0, Ore, ""), atHQ, false},
+		{"destroyed robot waits", Wait(2, ""), destroyed, true},
+		{"destroyed robot moves", Move(2, Point{3, 3}, ""), destroyed, false},
+		{"action of another robot", Wait(3, ""), field, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.a.Validate(validationGame, c.robot); (err == nil) != c.valid {
+				t.Errorf("Validate(%+v) = %v, want valid %v", c.a, err, c.valid)
+			}
+		})
+	}
+}
+
+func TestDigsNow(t *testing.T) {
+	robot := Entity{Point{5, 5}, 1, MyRobot, Nothing}
+	destroyed := Entity{Point{Nothing, Nothing}, 1, MyRobot, Nothing}
+	for _, c := range []struct {
+		a     Action
+		robot Entity
+		want  bool
+	}{
+		{Dig(1, Point{5, 5}, ""), robot, true},
+		{Dig(1, Point{5, 6}, ""), robot, true},
+		{Dig(1, Point{6, 6}, ""), robot, false},
+		{Dig(1, Point{5, 5}, ""), destroyed, false},
+		{Move(1, Point{5, 6}, ""), robot, false},
+	} {
+		if got := c.a.DigsNow(c.robot); got != c.want {
+			t.Errorf("%v.DigsNow(%v) = %v, want %v", c.a, c.robot.Point, got, c.want)
+		}
+	}
+}
+
+func TestValidateTurn(t *testing.T) {
+	ti := TurnInput{MyRobots: EntityMap{
+		0: {Point{0, 3}, 0, MyRobot, Nothing},
+		1: {Point{28, 5}, 1, MyRobot, Nothing},
+		2: {Point{Nothing, Nothing}, 2, MyRobot, Nothing},
+		3: {Point{1, 14}, 3, MyRobot, Nothing},
+		4: {Point{3, 3}, 4, MyRobot, Nothing},
+	}}
+	for _, c := range []struct {
+		name    string
+		actions []Action
+		want    []Action
+	}{
+		{
+			"valid turn",
+			[]Action{Request(0, Radar, "a"), Dig(1, Point{29, 5}, "b"), Wait(2, ""), Move(3, Point{0, 14}, "d"), Dig(4, Point{3, 4}, "e")},
+			[]Action{Request(0, Radar, "a"), Dig(1, Point{29, 5}, "b"), Wait(2, ""), Move(3, Point{0, 14}, "d"), Dig(4, Point{3, 4}, "e")},
+		},
+		{
+			"invalid actions",
+			[]Action{Move(0, Point{-2, 3}, "a"), Dig(1, Point{31, 5}, "b"), Move(2, Point{4, 4}, "c"), Dig(3, Point{0, 14}, "d"), Request(4, Trap, "e")},
+			[]Action{Move(0, Point{0, 3}, "a"), Dig(1, Point{29, 5}, "b"), Wait(2, "c"), Move(3, Point{0, 14}, "d"), Wait(4, "e")},
+		},
+		{
+			"missing and misnumbered actions",
+			[]Action{Wait(0, ""), Move(7, Point{4, 4}, "b")},
+			[]Action{Wait(0, ""), Move(1, Point{4, 4}, "b"), Wait(2, "missing"), Wait(3, "missing"), Wait(4, "missing")},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := validationGame.ValidateTurn(&ti, c.actions)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ValidateTurn =\n%v\nwant\n%v", got, c.want)
+			}
+			for i, a := range got {
+				if err := a.Validate(validationGame, ti.PlayerRobots()[i]); err != nil {
+					t.Errorf("action %d still invalid: %v", i, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+//MaxReplans bounds how often a turn is planned again around unsafe digs
+const MaxReplans = 3
+
+//TrapField holds the cells we believe hold a trap: our own and suspected enemy items
+type TrapField map[Point]bool
+
+//Traps returns our traps and the suspected enemy items, every suspected item may be a trap
+func (o GameObject) Traps(ti *TurnInput) TrapField {
+	field := make(TrapField)
+	for p, hole := range ti.HoleTiles {
+		if hole == MyTrap || ti.Suspected.Suspected(p) {
+			field[p] = true
+		}
+	}
+	return field
+}
+
+//Blast returns the cells destroyed when a dig at p sets off a trap, chained traps next to an
+//exploding trap go off as well
+func (f TrapField) Blast(p Point) map[Point]bool {
+	cells := make(map[Point]bool)
+	if !f[p] {
+		return cells
+	}
+	exploded := map[Point]bool{p: true}
+	queue := []Point{p}
+	for len(queue) > 0 {
+		var trap Point
+		trap, queue = queue[0], queue[1:]
+		for _, q := range []Point{trap, trap.Add(Up), trap.Add(Down), trap.Add(Left), trap.Add(Right)} {
+			cells[q] = true
+			if f[q] && !exploded[q] {
+				exploded[q] = true
+				queue = append(queue, q)
+			}
+		}
+	}
+	return cells
+}
+
+//Casualties returns our robots destroyed when the digs resolve, digs happen before robots move
+func (o GameObject) Casualties(ti *TurnInput, field TrapField, digs []Point) []int {
+	cells := make(map[Point]bool)
+	for _, p := range digs {
+		for q := range field.Blast(p) {
+			cells[q] = true
+		}
+	}
+	casualties := make([]int, 0)
+	for _, r := range ti.PlayerRobots() {
+		if !r.Destroyed() && cells[r.Point] {
+			casualties = append(casualties, r.ID)
+		}
+	}
+	return casualties
+}
+
+//digsThisTurn returns the DIG targets of the actions whose robots are next to them, by action index
+func digsThisTurn(ti *TurnInput, actions []Action) map[int]Point {
+	digs := make(map[int]Point)
+	for i, r := range ti.PlayerRobots() {
+		if i >= len(actions) {
+			break
+		}
+		if actions[i].DigsNow(r) {
+			digs[i] = actions[i].Target
+		}
+	}
+	return digs
+}
+
+//UnsafeDigs returns the DIG targets of this turn that would destroy one of our robots, deliberate
+//detonations excluded
+func (o GameObject) UnsafeDigs(ti *TurnInput, actions []Action) map[Point]bool {
+	field := o.Traps(ti)
+	unsafe := make(map[Point]bool)
+	robots := ti.PlayerRobots()
+	for i, target := range digsThisTurn(ti, actions) {
+		if trap, ok := ti.Detonations[robots[i].ID]; ok && trap == target {
+			continue
+		}
+		if len(o.Casualties(ti, field, []Point{target})) > 0 {
+			unsafe[target] = true
+		}
+	}
+	return unsafe
+}
+
+//SafeActions plans the turn again without the unsafe digs, digs still unsafe after MaxReplans wait
+func (o GameObject) SafeActions(ti *TurnInput, plan func() []Action) []Action {
+	actions := plan()
+	for i := 0; i < MaxReplans; i++ {
+		unsafe := o.UnsafeDigs(ti, actions)
+		if len(unsafe) == 0 {
+			return actions
+		}
+		for p := range unsafe {
+			Debug("unsafe dig %v\n", p)
+			ti.Unsafe[p] = true
+		}
+		actions = plan()
+	}
+	unsafe := o.UnsafeDigs(ti, actions)
+	for i, target := range digsThisTurn(ti, actions) {
+		if unsafe[target] {
+			actions[i] = Wait(actions[i].Robot, "unsafe")
+		}
+	}
+	return actions
+}
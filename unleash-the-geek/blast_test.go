@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+//cross returns a cell and its four neighbours
+func cross(p Point) []Point {
+	return []Point{p, p.Add(Up), p.Add(Down), p.Add(Left), p.Add(Right)}
+}
+
+func TestBlast(t *testing.T) {
+	field := TrapField{{5, 5}: true, {6, 5}: true, {6, 6}: true, {9, 5}: true}
+	chain := make(map[Point]bool)
+	for _, trap := range []Point{{5, 5}, {6, 5}, {6, 6}} {
+		for _, p := range cross(trap) {
+			chain[p] = true
+		}
+	}
+	single := make(map[Point]bool)
+	for _, p := range cross(Point{9, 5}) {
+		single[p] = true
+	}
+	for _, c := range []struct {
+		name string
+		dig  Point
+		want map[Point]bool
+	}{
+		{"chain", Point{5, 5}, chain},
+		{"chain from its end", Point{6, 6}, chain},
+		{"single trap", Point{9, 5}, single},
+		{"no trap", Point{7, 5}, map[Point]bool{}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := field.Blast(c.dig); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Blast(%v) = %v, want %v", c.dig, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCasualties(t *testing.T) {
+	ti := TurnInput{MyRobots: EntityMap{
+		0: {Point{5, 4}, 0, MyRobot, Nothing},
+		1: {Point{7, 6}, 1, MyRobot, Nothing},
+		2: {Point{8, 5}, 2, MyRobot, Nothing},
+		3: {Point{Nothing, Nothing}, 3, MyRobot, Nothing},
+	}}
+	field := TrapField{{5, 5}: true, {6, 5}: true, {6, 6}: true}
+	if got := (GameObject{}).Casualties(&ti, field, []Point{{6, 6}}); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("Casualties = %v, want robots 0 and 1", got)
+	}
+}
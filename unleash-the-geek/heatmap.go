@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Heatmap is the prior chance of every cell to hold ore, indexed [y][x]
+type Heatmap [][]float64
+
+//DefaultPriors are loaded with -priors, nil keeps the random blind dig
+var DefaultPriors Heatmap
+
+//OreStats counts first radar readings per game by column and row
+type OreStats struct {
+	Width, Height int
+	Games         int
+	FoundX, SeenX []int
+	FoundY, SeenY []int
+}
+
+//NewOreStats creates empty statistics for a map size
+func NewOreStats(width, height int) *OreStats {
+	return &OreStats{
+		Width: width, Height: height,
+		FoundX: make([]int, width), SeenX: make([]int, width),
+		FoundY: make([]int, height), SeenY: make([]int, height),
+	}
+}
+
+//Add aggregates a recorded game, every cell counts once with its first reading since digs deplete ore
+func (s *OreStats) Add(history []TurnInput) {
+	first := make(map[Point]int)
+	for _, ti := range history {
+		for p, ore := range ti.RadarTiles {
+			if _, ok := first[p]; !ok {
+				first[p] = ore
+			}
+		}
+	}
+	for p, ore := range first {
+		if p.X >= s.Width || p.Y >= s.Height {
+			continue
+		}
+		s.SeenX[p.X]++
+		s.SeenY[p.Y]++
+		if ore > 0 {
+			s.FoundX[p.X]++
+			s.FoundY[p.Y]++
+		}
+	}
+	s.Games++
+}
+
+func rate(found, seen int) float64 {
+	if seen == 0 {
+		return 0
+	}
+	return float64(found) / float64(seen)
+}
+
+//Heatmap combines column and row rates assuming they are independent
+func (s *OreStats) Heatmap() Heatmap {
+	found, seen := 0, 0
+	for x := range s.SeenX {
+		found += s.FoundX[x]
+		seen += s.SeenX[x]
+	}
+	overall := rate(found, seen)
+	h := make(Heatmap, s.Height)
+	for y := range h {
+		h[y] = make([]float64, s.Width)
+		for x := range h[y] {
+			if overall > 0 {
+				h[y][x] = rate(s.FoundX[x], s.SeenX[x]) * rate(s.FoundY[y], s.SeenY[y]) / overall
+			}
+		}
+	}
+	return h
+}
+
+func (s *OreStats) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "games: %d\ncolumn ore rate:", s.Games)
+	for x := range s.SeenX {
+		fmt.Fprintf(&sb, " %.2f", rate(s.FoundX[x], s.SeenX[x]))
+	}
+	sb.WriteString("\nrow ore rate:")
+	for y := range s.SeenY {
+		fmt.Fprintf(&sb, " %.2f", rate(s.FoundY[y], s.SeenY[y]))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func (h Heatmap) String() string {
+	var sb strings.Builder
+	for _, row := range h {
+		cells := make([]string, len(row))
+		for x, v := range row {
+			cells[x] = strconv.FormatFloat(v, 'f', 3, 64)
+		}
+		fmt.Fprintln(&sb, strings.Join(cells, " "))
+	}
+	return sb.String()
+}
+
+//At returns the prior of a cell, 0 outside of the heatmap
+func (h Heatmap) At(p Point) float64 {
+	if p.Y < 0 || p.Y >= len(h) || p.X < 0 || p.X >= len(h[p.Y]) {
+		return 0
+	}
+	return h[p.Y][p.X]
+}
+
+//ParseHeatmap reads a heatmap as written by String
+func ParseHeatmap(r io.Reader) (Heatmap, error) {
+	h := make(Heatmap, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]float64, len(fields))
+		for x, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("heatmap row %d: %v", len(h), err)
+			}
+			row[x] = v
+		}
+		h = append(h, row)
+	}
+	return h, scanner.Err()
+}
+
+//LoadHeatmap reads a heatmap file
+func LoadHeatmap(path string) (Heatmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseHeatmap(f)
+}
+
+//ReadHistory parses a recorded input stream into its turn inputs
+func ReadHistory(r io.Reader) (GameObject, []TurnInput) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1000000), 1000000)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	history := make([]TurnInput, 0)
+	if len(lines) == 0 {
+		return GameObject{}, history
+	}
+	game := NewGameObject(bufio.NewScanner(strings.NewReader(lines[0])), 0)
+	for i := 1; i+game.Height+1 < len(lines); {
+		var entities int
+		fmt.Sscan(lines[i+game.Height+1], &entities)
+		end := i + game.Height + 2 + entities
+		if end > len(lines) {
+			break
+		}
+		block := strings.Join(lines[i:end], "\n")
+		history = append(history, NewTurnInput(bufio.NewScanner(strings.NewReader(block)), &game))
+		i = end
+	}
+	return game, history
+}
+
+//OreHeatmap aggregates recorded games and prints the statistics and the heatmap
+func OreHeatmap(paths []string) error {
+	var stats *OreStats
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		game, history := ReadHistory(f)
+		f.Close()
+		if stats == nil {
+			stats = NewOreStats(game.Width, game.Height)
+		}
+		stats.Add(history)
+	}
+	if stats == nil {
+		return fmt.Errorf("heatmap: no recorded games")
+	}
+	fmt.Fprint(os.Stderr, stats)
+	fmt.Print(stats.Heatmap())
+	return nil
+}
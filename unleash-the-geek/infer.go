@@ -0,0 +1,108 @@
+package main
+
+//Inference confidences
+const (
+	BuryConfidence     = 0.9 //a robot digs after waiting at the headquarters
+	DigConfidence      = 0.1 //a robot digs without being seen requesting
+	SuspicionThreshold = 0.5
+)
+
+//Suspicion maps holes to the confidence that an enemy radar or trap is buried in them
+type Suspicion map[Point]float64
+
+//add combines a new observation with the existing confidence
+func (s Suspicion) add(p Point, confidence float64) {
+	s[p] = 1 - (1-s[p])*(1-confidence)
+}
+
+//Suspected reports if a hole should not be dug
+func (s Suspicion) Suspected(p Point) bool {
+	return s[p] >= SuspicionThreshold
+}
+
+//InferEnemyItems replays the history and the current turn to find holes where enemy robots likely
+//buried an item: a robot standing still at the headquarters requests an item, a robot standing
+//still next to a new hole dug it, one that requested may also bury in an old hole
+func (o GameObject) InferEnemyItems(ti *TurnInput) Suspicion {
+	turns := append(append([]TurnInput{}, o.History...), *ti)
+	suspicion := make(Suspicion)
+	carrying := make(map[int]bool)
+	for i := 1; i < len(turns); i++ {
+		prev, cur := turns[i-1], turns[i]
+		for _, robot := range cur.Enemies() {
+			id := robot.ID
+			last, ok := prev.EnemyRobots[id]
+			if !ok {
+				continue
+			}
+			if robot.Destroyed() {
+				if !last.Destroyed() {
+					suspicion.clear(last.Point)
+				}
+				delete(carrying, id)
+				continue
+			}
+			if robot.Point != last.Point {
+				continue
+			}
+			if robot.X == 0 {
+				carrying[id] = true
+				continue
+			}
+			holes := make([]Point, 0)
+			for _, p := range append(robot.Neigbours(&o), robot.Point) {
+				_, before := prev.HoleTiles[p]
+				if _, now := cur.HoleTiles[p]; now && !before && !contains(holes, p) {
+					holes = append(holes, p)
+				}
+			}
+			if len(holes) == 0 && carrying[id] {
+				for _, p := range append(robot.Neigbours(&o), robot.Point) {
+					if _, now := cur.HoleTiles[p]; now && !contains(holes, p) {
+						holes = append(holes, p)
+					}
+				}
+			}
+			if len(holes) == 0 {
+				continue
+			}
+			confidence := DigConfidence
+			if carrying[id] {
+				confidence = BuryConfidence
+			}
+			for _, p := range holes {
+				suspicion.add(p, confidence/float64(len(holes)))
+			}
+			delete(carrying, id)
+		}
+		for id, robot := range cur.MyRobots {
+			if last, ok := prev.MyRobots[id]; ok && robot.Destroyed() && !last.Destroyed() {
+				suspicion.clear(last.Point)
+			}
+		}
+	}
+	for p, hole := range ti.HoleTiles {
+		if hole == MyRadar || hole == MyTrap {
+			delete(suspicion, p)
+		}
+	}
+	return suspicion
+}
+
+//clear removes the suspicions around an explosion, the trap that went off is gone
+func (s Suspicion) clear(p Point) {
+	for q := range s {
+		if q.Distance(p) <= 1 {
+			delete(s, q)
+		}
+	}
+}
+
+func contains(list []Point, p Point) bool {
+	for _, q := range list {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
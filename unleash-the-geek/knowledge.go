@@ -0,0 +1,159 @@
+package main
+
+import "sort"
+
+//Cell states
+const (
+	Unknown = iota
+	KnownOre
+	Depleted
+	ProbablyOre
+)
+
+//Knowledge tuning
+const (
+	OrePrior         = 0.1  //chance an unseen cell holds ore
+	OwnDigBelief     = 0.5  //chance ore is left after we took one unit from an unseen cell
+	EnemyDigBelief   = 0.6  //chance ore is left where an enemy dug without us seeing the cell
+	StaleBelief      = 0.8  //chance known ore is still there after StaleTurns without a reading
+	ProbableOreLimit = 0.5  //belief from which a cell counts as ore
+	KnowledgeDecay   = 0.95 //per turn decay of beliefs towards the prior
+	StaleTurns       = 30
+)
+
+//Cell is what we believe about the ore of a tile
+type Cell struct {
+	State  int
+	Ore    int     //last known ore count
+	Belief float64 //chance the cell still holds ore
+	Seen   int     //turn of the last radar reading or dig
+}
+
+//Knowledge is the persistent per cell ore belief, it merges radar readings and digs of both players
+type Knowledge map[Point]*Cell
+
+func (k Knowledge) cell(p Point) *Cell {
+	c, ok := k[p]
+	if !ok {
+		c = &Cell{State: Unknown, Belief: OrePrior}
+		k[p] = c
+	}
+	return c
+}
+
+//decay forgets old observations: known ore turns probable, beliefs fall back to the prior
+func (c *Cell) decay(turn int) {
+	switch c.State {
+	case KnownOre:
+		if turn-c.Seen > StaleTurns {
+			c.State, c.Belief = ProbablyOre, StaleBelief
+		}
+	case ProbablyOre:
+		c.Belief = OrePrior + (c.Belief-OrePrior)*KnowledgeDecay
+		if c.Belief < ProbableOreLimit {
+			c.State = Unknown
+		}
+	}
+}
+
+//read stores a radar reading
+func (c *Cell) read(ore, turn int) {
+	c.Ore, c.Seen = ore, turn
+	if ore > 0 {
+		c.State, c.Belief = KnownOre, 1
+	} else {
+		c.State, c.Belief = Depleted, 0
+	}
+}
+
+//dug records one unit of ore taken from a cell we don't see, belief is used for unknown counts
+func (c *Cell) dug(turn int, belief float64) {
+	c.Seen = turn
+	switch c.State {
+	case KnownOre, ProbablyOre:
+		if c.Ore > 0 {
+			c.Ore--
+		}
+		if c.Ore == 0 && c.State == KnownOre {
+			c.State, c.Belief = Depleted, 0
+		} else if c.State == ProbablyOre {
+			c.Belief = belief
+		}
+	case Unknown:
+		c.State, c.Belief = ProbablyOre, belief
+	}
+}
+
+//Learn merges the current turn into the knowledge of the game
+func (o GameObject) Learn(ti *TurnInput) {
+	k := o.Knowledge
+	turn := len(o.History)
+	for _, c := range k {
+		c.decay(turn)
+	}
+	for p, ore := range ti.RadarTiles {
+		k.cell(p).read(ore, turn)
+	}
+	if len(o.History) == 0 {
+		return
+	}
+	prev := o.History[len(o.History)-1]
+	for i, robot := range prev.PlayerRobots() {
+		if i >= len(prev.Actions) {
+			break
+		}
+		if !prev.Actions[i].DigsNow(robot) {
+			continue
+		}
+		target := prev.Actions[i].Target
+		now, ok := ti.MyRobots[robot.ID]
+		if _, visible := ti.RadarTiles[target]; !ok || now.Destroyed() || visible || robot.Item == Ore {
+			continue
+		}
+		if now.Item == Ore {
+			k.cell(target).dug(turn, OwnDigBelief)
+		} else {
+			k.cell(target).read(0, turn)
+		}
+	}
+	for id, robot := range ti.EnemyRobots {
+		last, ok := prev.EnemyRobots[id]
+		if !ok || robot.Destroyed() || robot.Point != last.Point || robot.X == 0 {
+			continue
+		}
+		for _, p := range append(robot.Neigbours(&o), robot.Point) {
+			_, before := prev.HoleTiles[p]
+			_, now := ti.HoleTiles[p]
+			if _, visible := ti.RadarTiles[p]; now && !before && !visible {
+				k.cell(p).dug(turn, EnemyDigBelief)
+			}
+		}
+	}
+}
+
+//OreTiles returns the cells believed to hold ore, known ore by count first, then by belief
+func (k Knowledge) OreTiles() []Point {
+	list := make([]Point, 0)
+	for p, c := range k {
+		if c.State == KnownOre || c.State == ProbablyOre && c.Belief >= ProbableOreLimit {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := k[list[i]], k[list[j]]
+		if (a.State == KnownOre) != (b.State == KnownOre) {
+			return a.State == KnownOre
+		}
+		if a.Ore != b.Ore {
+			return a.Ore > b.Ore
+		}
+		if a.Belief != b.Belief {
+			return a.Belief > b.Belief
+		}
+		if list[i].X != list[j].X {
+			return list[i].X < list[j].X
+		}
+		return list[i].Y < list[j].Y
+	})
+	return list
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"os"
@@ -11,13 +13,13 @@ import (
 	"strings"
 )
 
-func random(min, max int) int {
-	return rand.Intn(max-min) + min
+//random returns a number in [min, max) from the game's source
+func (o GameObject) random(min, max int) int {
+	return o.Rand.Intn(max-min) + min
 }
 
 /*
 TODOS:
- - Optimize the radar placement. keep radar positions, radar coverage in mind, find out if ore placement is somehow biased
  - optimize dig target aquisition. dont consider empty holes, dig randomly if no ore is available, keep move distance in mind
  - lookup enemy radars and traps. tile history, item history for robots
 */
@@ -134,17 +136,24 @@ type EntityMap map[int]Entity
 
 //GameObject holds the game information
 type GameObject struct {
-	Width   int
-	Height  int
-	History []TurnInput
+	Width       int
+	Height      int
+	History     []TurnInput
+	Knowledge   Knowledge
+	Commitments map[int]Point
+	Seed        int64
+	Rand        *rand.Rand
+	Priors      Heatmap
 }
 
-//NewGameObject creates GameObjects
-func NewGameObject(scanner *bufio.Scanner) GameObject {
-	obj := GameObject{}
+//NewGameObject creates GameObjects, every turn draws from a source seeded with seed and the turn input
+func NewGameObject(scanner *bufio.Scanner, seed int64) GameObject {
+	obj := GameObject{Seed: seed, Rand: rand.New(rand.NewSource(seed)), Priors: DefaultPriors}
 	scanner.Scan()
 	fmt.Sscan(scanner.Text(), &obj.Width, &obj.Height)
 	obj.History = make([]TurnInput, 0)
+	obj.Knowledge = make(Knowledge)
+	obj.Commitments = make(map[int]Point)
 	return obj
 }
 
@@ -160,6 +169,10 @@ type TurnInput struct {
 	HoleTiles     TileMap
 	MyRobots      EntityMap
 	EnemyRobots   EntityMap
+	Suspected     Suspicion
+	Unsafe        map[Point]bool
+	Detonations   map[int]Point
+	Actions       []Action
 }
 
 //OreTiles returns a slice of Points
@@ -178,16 +191,26 @@ func (ti TurnInput) OreTiles() []Point {
 
 //PlayerRobots gets robots sorted by id
 func (ti TurnInput) PlayerRobots() []Entity {
+	return ti.MyRobots.Sorted()
+}
+
+//Enemies gets enemy robots sorted by id
+func (ti TurnInput) Enemies() []Entity {
+	return ti.EnemyRobots.Sorted()
+}
+
+//Sorted returns the entities sorted by id
+func (m EntityMap) Sorted() []Entity {
 	var keys []int
-	for k := range ti.MyRobots {
+	for k := range m {
 		keys = append(keys, k)
 	}
 	sort.Ints(keys)
-	robots := make([]Entity, 0)
+	entities := make([]Entity, 0)
 	for _, id := range keys {
-		robots = append(robots, ti.MyRobots[id])
+		entities = append(entities, m[id])
 	}
-	return robots
+	return entities
 }
 
 //ToSeed returns the turn hash
@@ -213,6 +236,7 @@ func NewTurnInput(scanner *bufio.Scanner, game *GameObject) TurnInput {
 	ti.HoleTiles = make(TileMap)
 	ti.MyRobots = make(EntityMap)
 	ti.EnemyRobots = make(EntityMap)
+	ti.Unsafe = make(map[Point]bool)
 	scanner.Scan()
 	fmt.Sscan(scanner.Text(), &ti.MyScore, &ti.EnemyScore)
 
@@ -308,6 +332,13 @@ func NewTurnInput(scanner *bufio.Scanner, game *GameObject) TurnInput {
 	return ti
 }
 
+//SortPoints sorts a slice of points in reading order
+func SortPoints(list []Point) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Y < list[j].Y || list[i].Y == list[j].Y && list[i].X < list[j].X
+	})
+}
+
 //PointsByDistance sorts a slice of points by distance to a point
 func PointsByDistance(list []Point, p Point) {
 	sort.SliceStable(list, (func(i, j int) bool {
@@ -317,110 +348,93 @@ func PointsByDistance(list []Point, p Point) {
 
 //TakeTurn returns the turns actions
 func (o GameObject) TakeTurn(ti *TurnInput) []string {
-	actions := make([]string, 0)
-	rand.Seed(42)
-	oreTiles := ti.OreTiles()
-
-	for _, r := range ti.PlayerRobots() {
-		PointsByDistance(oreTiles, r.Point)
-		action := o.RobotAction(r, ti, oreTiles)
-		if strings.Contains(action, "DIG") && len(oreTiles) > 0 {
-			oreTiles = oreTiles[1:]
+	o.Rand.Seed(o.Seed + ti.ToSeed() + int64(len(o.History)))
+	ti.Suspected = o.InferEnemyItems(ti)
+	o.Learn(ti)
+	ti.Detonations = o.Detonations(ti)
+	radarHolder, trapHolder := ti.RadarHolder, ti.TrapHolder
+	actions := o.SafeActions(ti, func() []Action {
+		ti.RadarHolder, ti.TrapHolder = radarHolder, trapHolder
+		tasks := o.AssignTasks(ti)
+		actions := make([]Action, 0)
+		for _, r := range ti.PlayerRobots() {
+			actions = append(actions, o.RobotAction(r, ti, tasks[r.ID]))
 		}
-		actions = append(actions, action)
+		return actions
+	})
+	ti.Actions = o.ValidateTurn(ti, actions)
+	commands := make([]string, len(ti.Actions))
+	for i, a := range ti.Actions {
+		commands[i] = a.String()
 	}
-	return actions
+	return commands
 }
 
-//RobotAction decides the action a robot takes
-func (o GameObject) RobotAction(robot Entity, ti *TurnInput, oreTiles []Point) string {
-	if robot.Destroyed() {
-		return "WAIT"
-	}
-	if robot.Item == Ore {
-		return fmt.Sprintf("MOVE 0 %v ID(%v)", robot.Y, robot.ID)
-	}
-	if robot.Item == Radar {
-		return o.RadarAction(robot, ti)
-	}
-	if robot.Item == Trap {
-		return o.TrapAction(robot, ti)
-	}
-	if ti.RadarHolder == Nothing && ti.RadarCooldown == 0 && robot.Point.Y > 0 && len(oreTiles) < 2*len(ti.MyRobots) {
-		ti.RadarHolder = robot.ID
-		return "REQUEST RADAR"
-	}
-	if ti.TrapHolder == Nothing && ti.TrapCooldown == 0 {
-		ti.TrapHolder = robot.ID
-		return "REQUEST TRAP"
-	}
-	if len(oreTiles) > 0 {
-		var tile Point
-		for len(oreTiles) > 0 {
-			tile, oreTiles = oreTiles[0], oreTiles[1:]
-			if hole, ok := ti.HoleTiles[tile]; !ok || (ok && hole != MyTrap) {
-				break
-			}
-			//if tile.Hole == 0 {
+//message labels a robot with its task in the viewer
+func message(robot Entity, task Task) string {
+	return fmt.Sprintf("ID(%v) %v", robot.ID, task)
+}
+
+//RobotAction turns the task of a robot into its command
+func (o GameObject) RobotAction(robot Entity, ti *TurnInput, task Task) Action {
+	msg := message(robot, task)
+	switch task.Kind {
+	case TaskReturn:
+		return Move(robot.ID, Point{0, robot.Y}, msg)
+	case TaskRadar:
+		return o.RadarAction(robot, ti, msg)
+	case TaskTrap:
+		return o.TrapAction(robot, ti, msg)
+	case TaskRequestRadar, TaskRequestTrap:
+		if robot.X > 0 {
+			return Move(robot.ID, Point{0, robot.Y}, msg)
 		}
-		return fmt.Sprintf("DIG %v %v ID(%v)", tile.X, tile.Y, robot.ID)
-		//}
-	}
-	target := Point{1, robot.Y}
-	for {
-		target = target.Add(Point{random(1, MoveRange), 0})
-		if _, ok := ti.HoleTiles[target]; !ok {
-			break
+		if task.Kind == TaskRequestRadar {
+			return Request(robot.ID, Radar, msg)
 		}
+		return Request(robot.ID, Trap, msg)
+	case TaskDig, TaskBlindDig, TaskDetonate:
+		return Dig(robot.ID, task.Target, msg)
 	}
-
-	return fmt.Sprintf("DIG %v %v ID(%v)", target.X, target.Y, robot.ID)
+	return Wait(robot.ID, msg)
 }
 
 //TrapAction places Traps
-func (o GameObject) TrapAction(robot Entity, ti *TurnInput) string {
+func (o GameObject) TrapAction(robot Entity, ti *TurnInput, msg string) Action {
+	if p, ok := o.PlanTrap(robot, ti); ok {
+		return Dig(robot.ID, p, msg)
+	}
 	targets := make([]Point, 0)
 	for k := range ti.RadarTiles {
 		targets = append(targets, k)
 	}
+	SortPoints(targets)
 	PointsByDistance(targets, robot.Point)
 	target := robot.Add(
 		Point{
-			random(-1, 1),
-			random(-1, 1),
+			o.random(-1, 1),
+			o.random(-1, 1),
 		},
-	)
+	).Clamp(&o)
 	for len(targets) > 0 {
 		target, targets = targets[0], targets[1:]
-		if ti.RadarTiles[target] > 1 {
+		if ti.RadarTiles[target] > 1 && ti.HoleTiles[target] != MyTrap && !ti.Suspected.Suspected(target) && !ti.Unsafe[target] {
 			break
 		}
 	}
-
-	return fmt.Sprintf("DIG %v %v ID(%v)", target.X, target.Y, robot.ID)
+	if target.X == 0 {
+		target.X = 1
+	}
+	return Dig(robot.ID, target, msg)
 }
 
 //RadarAction places Radars
-func (o GameObject) RadarAction(robot Entity, ti *TurnInput) string {
-	p := Point{5, robot.Y}
-	targets := make([]Point, 0)
-	for p, hole := range ti.HoleTiles {
-		if hole == MyRadar {
-			rd := p.Add(Point{4, 5}) //.Clamp(&o)
-			if _, ok := ti.HoleTiles[rd]; !ok && rd.X < o.Width && rd.Y < o.Height {
-				targets = append(targets, rd.Clamp(&o))
-			}
-			ru := p.Add(Point{4, -5}) //.Clamp(&o)
-			if _, ok := ti.HoleTiles[ru]; !ok && ru.X < o.Width && ru.Y > 0 {
-				targets = append(targets, ru.Clamp(&o))
-			}
-		}
+func (o GameObject) RadarAction(robot Entity, ti *TurnInput, msg string) Action {
+	p, ok := o.PlanRadar(robot.Point, ti)
+	if !ok {
+		p = Point{5, robot.Y}
 	}
-	if len(targets) > 0 {
-		PointsByDistance(targets, robot.Point)
-		p = targets[0]
-	}
-	return fmt.Sprintf("DIG %v %v ID(%v)", p.X, p.Y, robot.ID)
+	return Dig(robot.ID, p, msg)
 }
 
 /*
@@ -434,11 +448,48 @@ Action order for one turn
 6. Ore is delivered to the headquarters.
 */
 
+var selfplay = flag.Int("selfplay", 0, "play n local referee matches of the bot against itself")
+var seed = flag.Int64("seed", 42, "seed of the random exploration, and of the maps in self-play")
+var record = flag.String("record", "", "copy the input to a file, in self-play write every game into a directory")
+var heatmap = flag.Bool("heatmap", false, "print the ore heatmap of the recorded games given as arguments")
+var priors = flag.String("priors", "", "heatmap file guiding the blind dig")
+
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
+	flag.Parse()
+	if *heatmap {
+		if err := OreHeatmap(flag.Args()); err != nil {
+			Debug("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *priors != "" {
+		h, err := LoadHeatmap(*priors)
+		if err != nil {
+			Debug("priors: %v\n", err)
+			os.Exit(1)
+		}
+		DefaultPriors = h
+	}
+	if *selfplay > 0 {
+		SelfPlay(*selfplay, [2]Strategy{GameObject.TakeTurn, GameObject.TakeTurn}, *seed, *record)
+		return
+	}
+	var input io.Reader = os.Stdin
+	var recording *os.File
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			Debug("record: %v\n", err)
+			os.Exit(1)
+		}
+		recording = f
+		input = io.TeeReader(os.Stdin, f)
+	}
+	scanner := bufio.NewScanner(input)
 	scanner.Buffer(make([]byte, 1000000), 1000000)
-	game := NewGameObject(scanner)
-	for {
+	game := NewGameObject(scanner, *seed)
+	for turn := 0; turn < MaxTurns; turn++ {
 		ti := NewTurnInput(scanner, &game)
 		Debug("RadarLength: %v, HoleLength: %v\n", len(ti.RadarTiles), len(ti.HoleTiles))
 		actions := game.TakeTurn(&ti)
@@ -448,4 +499,10 @@ func main() {
 		}
 
 	}
+	if recording != nil {
+		if err := recording.Close(); err != nil {
+			Debug("record: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+//TestMain silences the debug output of the bots unless the tests run verbose
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() {
+		if null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			os.Stderr = null
+		}
+	}
+	os.Exit(m.Run())
+}
+
+//playRecorded plays a self-play match and returns the result, the input of player 0 and the
+//commands of both players
+func playRecorded(seed int64) (int, string, [][]string) {
+	commands := make([][]string, 0)
+	bot := func(o GameObject, ti *TurnInput) []string {
+		actions := o.TakeTurn(ti)
+		commands = append(commands, actions)
+		return actions
+	}
+	var record bytes.Buffer
+	result := PlayMatch(NewReferee(rand.New(rand.NewSource(seed))), [2]Strategy{bot, bot}, seed, &record)
+	return result, record.String(), commands
+}
+
+func TestSelfPlayDeterministic(t *testing.T) {
+	for _, seed := range []int64{7, 42} {
+		result, record, commands := playRecorded(seed)
+		again, recordAgain, commandsAgain := playRecorded(seed)
+		if result != again {
+			t.Errorf("seed %d: results %d and %d", seed, result, again)
+		}
+		if record != recordAgain {
+			t.Errorf("seed %d: the inputs of the two matches differ", seed)
+		}
+		if !reflect.DeepEqual(commands, commandsAgain) {
+			for i := range commands {
+				if i >= len(commandsAgain) || !reflect.DeepEqual(commands[i], commandsAgain[i]) {
+					t.Errorf("seed %d: commands differ first at turn %d", seed, i/2)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSelfPlaySeed(t *testing.T) {
+	_, record, _ := playRecorded(7)
+	_, other, _ := playRecorded(8)
+	if record == other {
+		t.Errorf("seeds 7 and 8 played the same match")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import "math"
+
+//Trap baiting
+const (
+	DigSiteMemory    = 10 //turns an enemy dig site attracts traps
+	DetonateMinKills = 2  //enemy robots a detonation has to destroy
+)
+
+//EnemyDigSites returns the holes enemy robots dug within the last DigSiteMemory turns with the
+//turn they were dug
+func (o GameObject) EnemyDigSites(ti *TurnInput) map[Point]int {
+	turns := append(append([]TurnInput{}, o.History...), *ti)
+	sites := make(map[Point]int)
+	for i := len(turns) - DigSiteMemory; i < len(turns); i++ {
+		if i < 1 {
+			continue
+		}
+		prev, cur := turns[i-1], turns[i]
+		for _, robot := range cur.Enemies() {
+			last, ok := prev.EnemyRobots[robot.ID]
+			if !ok || robot.Destroyed() || robot.Point != last.Point || robot.X == 0 {
+				continue
+			}
+			for _, p := range append(robot.Neigbours(&o), robot.Point) {
+				_, before := prev.HoleTiles[p]
+				if _, now := cur.HoleTiles[p]; now && !before {
+					sites[p] = i
+				}
+			}
+		}
+	}
+	return sites
+}
+
+//enemyInterest estimates how likely enemy robots dig a cell soon: close to where they dug
+//recently and ahead of where they are heading
+func (o GameObject) enemyInterest(p Point, ti *TurnInput, sites map[Point]int) float64 {
+	turn := len(o.History)
+	interest := 0.0
+	points := make([]Point, 0, len(sites))
+	for site := range sites {
+		points = append(points, site)
+	}
+	SortPoints(points)
+	for _, site := range points {
+		dug := sites[site]
+		recency := 1 - float64(turn-dug)/DigSiteMemory
+		interest += recency * math.Exp(-float64(p.Distance(site))/2)
+	}
+	if len(o.History) == 0 {
+		return interest
+	}
+	prev := o.History[len(o.History)-1]
+	for _, robot := range ti.Enemies() {
+		last, ok := prev.EnemyRobots[robot.ID]
+		if !ok || robot.Destroyed() || last.Destroyed() {
+			continue
+		}
+		heading := Point{robot.X - last.X, robot.Y - last.Y}
+		if heading.X < 0 {
+			continue //returning ore to the headquarters
+		}
+		interest += math.Exp(-float64(p.Distance(robot.Add(heading).Clamp(&o))) / 4)
+	}
+	return interest
+}
+
+//PlanTrap returns the ore cell where a trap most likely catches enemy robots, false if none
+func (o GameObject) PlanTrap(robot Entity, ti *TurnInput) (Point, bool) {
+	sites := o.EnemyDigSites(ti)
+	best, bestScore := Point{}, 0.0
+	for _, p := range o.Knowledge.OreTiles() {
+		hole := ti.HoleTiles[p]
+		if p.X == 0 || hole == MyTrap || hole == MyRadar || ti.Suspected.Suspected(p) || ti.Unsafe[p] {
+			continue
+		}
+		ore := math.Min(float64(o.Knowledge[p].Ore), 3)
+		if o.Knowledge[p].State != KnownOre {
+			ore = o.Knowledge[p].Belief
+		}
+		score := ore * o.enemyInterest(p, ti, sites) / float64(1+travelTurns(robot.Point, p, 1))
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+//Detonations picks traps to set off this turn: a robot next to our trap digs it when the chain
+//destroys at least DetonateMinKills enemy robots and none of our robots but the one digging
+func (o GameObject) Detonations(ti *TurnInput) map[int]Point {
+	field := o.Traps(ti)
+	detonations := make(map[int]Point)
+	used := make(map[Point]bool)
+	traps := make([]Point, 0)
+	for p, hole := range ti.HoleTiles {
+		if hole == MyTrap {
+			traps = append(traps, p)
+		}
+	}
+	SortPoints(traps)
+	for _, p := range traps {
+		if used[p] {
+			continue
+		}
+		blast := field.Blast(p)
+		kills := 0
+		for _, r := range ti.EnemyRobots {
+			if !r.Destroyed() && blast[r.Point] {
+				kills++
+			}
+		}
+		var caught []Entity
+		for _, r := range ti.PlayerRobots() {
+			if !r.Destroyed() && blast[r.Point] {
+				caught = append(caught, r)
+			}
+		}
+		if kills < DetonateMinKills || len(caught) != 1 || caught[0].Distance(p) > 1 {
+			continue
+		}
+		detonator := caught[0]
+		if _, busy := detonations[detonator.ID]; busy {
+			continue
+		}
+		Debug("DETONATE %v kills:%d\n", p, kills)
+		detonations[detonator.ID] = p
+		for q := range blast {
+			used[q] = true
+		}
+	}
+	return detonations
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetonations(t *testing.T) {
+	trap := Point{10, 5}
+	for _, c := range []struct {
+		name    string
+		mine    []Entity
+		enemies []Entity
+		want    map[int]Point
+	}{
+		{
+			"two enemies for the detonator",
+			[]Entity{{Point{9, 5}, 0, MyRobot, Nothing}, {Point{3, 3}, 1, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{10, 6}, 6, EnemyRobot, Nothing}},
+			map[int]Point{0: trap},
+		},
+		{
+			"a destroyed own robot is no loss",
+			[]Entity{{Point{9, 5}, 0, MyRobot, Ore}, {Point{Nothing, Nothing}, 1, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{10, 6}, 6, EnemyRobot, Nothing}},
+			map[int]Point{0: trap},
+		},
+		{
+			"one enemy",
+			[]Entity{{Point{9, 5}, 0, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{13, 5}, 6, EnemyRobot, Nothing}},
+			map[int]Point{},
+		},
+		{
+			"destroyed enemies",
+			[]Entity{{Point{9, 5}, 0, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{Nothing, Nothing}, 6, EnemyRobot, Nothing}},
+			map[int]Point{},
+		},
+		{
+			"a second own robot in the blast",
+			[]Entity{{Point{9, 5}, 0, MyRobot, Nothing}, {Point{11, 5}, 1, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{10, 6}, 6, EnemyRobot, Nothing}, {Point{10, 4}, 7, EnemyRobot, Nothing}},
+			map[int]Point{},
+		},
+		{
+			"no robot next to the trap",
+			[]Entity{{Point{8, 5}, 0, MyRobot, Nothing}},
+			[]Entity{{Point{10, 5}, 5, EnemyRobot, Nothing}, {Point{10, 6}, 6, EnemyRobot, Nothing}},
+			map[int]Point{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ti := TurnInput{HoleTiles: TileMap{trap: MyTrap}, MyRobots: EntityMap{}, EnemyRobots: EntityMap{}}
+			for _, r := range c.mine {
+				ti.MyRobots[r.ID] = r
+			}
+			for _, r := range c.enemies {
+				ti.EnemyRobots[r.ID] = r
+			}
+			if got := (GameObject{}).Detonations(&ti); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Detonations() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDetonationsChain(t *testing.T) {
+	//the chain reaches the enemy robots two traps away from the detonator
+	ti := TurnInput{
+		HoleTiles:   TileMap{{10, 5}: MyTrap, {11, 5}: MyTrap, {12, 5}: MyTrap},
+		MyRobots:    EntityMap{0: {Point{9, 5}, 0, MyRobot, Nothing}},
+		EnemyRobots: EntityMap{5: {Point{13, 5}, 5, EnemyRobot, Nothing}, 6: {Point{12, 6}, 6, EnemyRobot, Nothing}},
+	}
+	if got, want := (GameObject{}).Detonations(&ti), map[int]Point{0: {10, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Detonations() = %v, want %v", got, want)
+	}
+}
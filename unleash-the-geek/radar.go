@@ -0,0 +1,97 @@
+package main
+
+//Radar planning weights
+const (
+	HQDistanceWeight   = 0.05 //cells far from the headquarters are worth less
+	RadarTravelPenalty = 0.25 //value lost per turn the carrier walks
+	RefreshWeight      = 0.5  //known ore out of coverage, e.g. after the enemy dug up a radar
+)
+
+//MyRadars returns the positions of our radars
+func (ti TurnInput) MyRadars() []Point {
+	radars := make([]Point, 0)
+	for p, hole := range ti.HoleTiles {
+		if hole == MyRadar {
+			radars = append(radars, p)
+		}
+	}
+	return radars
+}
+
+//LostRadars returns our radars of the last turn that are gone
+func (o GameObject) LostRadars(ti *TurnInput) []Point {
+	lost := make([]Point, 0)
+	if len(o.History) == 0 {
+		return lost
+	}
+	for _, p := range o.History[len(o.History)-1].MyRadars() {
+		if ti.HoleTiles[p] != MyRadar {
+			lost = append(lost, p)
+		}
+	}
+	return lost
+}
+
+//coverageValue weights a cell a new radar would uncover
+func (o GameObject) coverageValue(p Point) float64 {
+	weight := 1 / (1 + HQDistanceWeight*float64(p.X))
+	c, ok := o.Knowledge[p]
+	if !ok {
+		return weight
+	}
+	switch c.State {
+	case Unknown, ProbablyOre:
+		return weight
+	case KnownOre:
+		return RefreshWeight * weight
+	}
+	return 0
+}
+
+//PlanRadar returns the cell whose radar uncovers the most valuable cells per turn of travel,
+//false if no cell adds coverage
+func (o GameObject) PlanRadar(carrier Point, ti *TurnInput) (Point, bool) {
+	radars := ti.MyRadars()
+	covered := func(p Point) bool {
+		for _, r := range radars {
+			if r.Distance(p) <= RadarRange {
+				return true
+			}
+		}
+		return false
+	}
+	value := make(map[Point]float64)
+	for y := 0; y < o.Height; y++ {
+		for x := 1; x < o.Width; x++ {
+			if p := (Point{x, y}); !covered(p) {
+				value[p] = o.coverageValue(p)
+			}
+		}
+	}
+	best, bestScore := Point{}, 0.0
+	for y := 0; y < o.Height; y++ {
+		for x := 1; x < o.Width; x++ {
+			p := Point{x, y}
+			if _, hole := ti.HoleTiles[p]; hole || ti.Suspected.Suspected(p) || ti.Unsafe[p] {
+				continue
+			}
+			gain := 0.0
+			for dy := -RadarRange; dy <= RadarRange; dy++ {
+				for dx := -RadarRange; dx <= RadarRange; dx++ {
+					q := p.Add(Point{dx, dy})
+					if p.Distance(q) <= RadarRange {
+						gain += value[q]
+					}
+				}
+			}
+			turns := 0
+			if d := carrier.Distance(p) - 1; d > 0 {
+				turns = (d + MoveRange - 1) / MoveRange
+			}
+			if score := gain / (1 + RadarTravelPenalty*float64(turns)); score > bestScore {
+				best, bestScore = p, score
+			}
+		}
+	}
+	return best, bestScore > 0
+}
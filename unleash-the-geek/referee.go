@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Referee constants
+const (
+	MapWidth     = 30
+	MapHeight    = 15
+	RobotCount   = 5
+	ItemCooldown = 5
+	MaxTurns     = 200
+	Draw         = 2
+)
+
+//Strategy is a bot as it plays in the arena
+type Strategy func(o GameObject, ti *TurnInput) []string
+
+//Referee is the full game state of a local match, robots and buried items are entities
+//whose EntityType is the owning player
+type Referee struct {
+	Width         int
+	Height        int
+	Ore           map[Point]int
+	Holes         map[Point]bool
+	Robots        []*Entity
+	Items         []*Entity
+	Score         [2]int
+	RadarCooldown [2]int
+	TrapCooldown  [2]int
+	Turn          int
+	nextID        int
+}
+
+//order is a parsed robot command
+type order struct {
+	robot *Entity
+	Action
+}
+
+//NewReferee creates a match with random ore veins and robot start rows
+func NewReferee(rng *rand.Rand) *Referee {
+	r := &Referee{
+		Width:  MapWidth,
+		Height: MapHeight,
+		Ore:    make(map[Point]int),
+		Holes:  make(map[Point]bool),
+	}
+	for veins := r.Width * r.Height / 30; veins > 0; veins-- {
+		center := Point{5 + rng.Intn(r.Width-6), rng.Intn(r.Height)}
+		radius := 1 + rng.Intn(2)
+		for y := center.Y - radius; y <= center.Y+radius; y++ {
+			for x := center.X - radius; x <= center.X+radius; x++ {
+				p := Point{x, y}
+				if r.inside(p) && p.X > 0 && center.Distance(p) <= radius && rng.Intn(10) < 6 {
+					r.Ore[p] += 1 + rng.Intn(3)
+				}
+			}
+		}
+	}
+	rows := rng.Perm(r.Height)[:RobotCount]
+	for player := 0; player < 2; player++ {
+		for _, y := range rows {
+			r.Robots = append(r.Robots, &Entity{Point{0, y}, r.nextID, player, Nothing})
+			r.nextID++
+		}
+	}
+	return r
+}
+
+func (r *Referee) inside(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < r.Width && p.Y < r.Height
+}
+
+//PlayerRobots returns the robots of a player sorted by id
+func (r *Referee) PlayerRobots(player int) []*Entity {
+	robots := make([]*Entity, 0, RobotCount)
+	for _, robot := range r.Robots {
+		if robot.EntityType == player {
+			robots = append(robots, robot)
+		}
+	}
+	return robots
+}
+
+//Visible reports if a player's radars cover a cell
+func (r *Referee) Visible(player int, p Point) bool {
+	for _, item := range r.Items {
+		if item.EntityType == player && item.Item == Radar && item.Distance(p) <= RadarRange {
+			return true
+		}
+	}
+	return false
+}
+
+//InitInput returns the first line a bot reads
+func (r *Referee) InitInput() string {
+	return fmt.Sprintf("%d %d\n", r.Width, r.Height)
+}
+
+//Input returns the turn input of a player, the format NewTurnInput parses
+func (r *Referee) Input(player int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d %d\n", r.Score[player], r.Score[1-player])
+	for y := 0; y < r.Height; y++ {
+		cells := make([]string, 0, 2*r.Width)
+		for x := 0; x < r.Width; x++ {
+			p := Point{x, y}
+			ore := "?"
+			if r.Visible(player, p) {
+				ore = strconv.Itoa(r.Ore[p])
+			}
+			hole := "0"
+			if r.Holes[p] {
+				hole = "1"
+			}
+			cells = append(cells, ore, hole)
+		}
+		fmt.Fprintln(&sb, strings.Join(cells, " "))
+	}
+	entities := make([]string, 0)
+	for _, robot := range r.Robots {
+		category, item := MyRobot, robot.Item
+		if robot.EntityType != player {
+			category, item = EnemyRobot, Nothing
+		}
+		entities = append(entities, fmt.Sprintf("%d %d %d %d %d", robot.ID, category, robot.X, robot.Y, item))
+	}
+	for _, item := range r.Items {
+		if item.EntityType == player {
+			entities = append(entities, fmt.Sprintf("%d %d %d %d %d", item.ID, item.Item, item.X, item.Y, Nothing))
+		}
+	}
+	fmt.Fprintf(&sb, "%d %d %d\n", len(entities), r.RadarCooldown[player], r.TrapCooldown[player])
+	for _, e := range entities {
+		fmt.Fprintln(&sb, e)
+	}
+	return sb.String()
+}
+
+//parseOrders maps the output lines of a player to its robots, missing or invalid lines wait
+func (r *Referee) parseOrders(player int, lines []string) []order {
+	orders := make([]order, 0, RobotCount)
+	bounds := GameObject{Width: r.Width, Height: r.Height}
+	for i, robot := range r.PlayerRobots(player) {
+		o := order{robot, Wait(robot.ID, "")}
+		if i < len(lines) {
+			a, err := ParseAction(robot.ID, lines[i])
+			if err == nil && a.Kind == ActionDig && a.Target.X == 0 {
+				a.Kind = ActionMove
+			}
+			if err == nil {
+				err = a.Validate(bounds, *robot)
+			}
+			if err == nil {
+				o.Action = a
+			}
+		}
+		orders = append(orders, o)
+	}
+	return orders
+}
+
+//step returns the cell within MoveRange that is closest to the target
+func (r *Referee) step(from, to Point) Point {
+	if from.Distance(to) <= MoveRange {
+		return to
+	}
+	best := from
+	for dy := -MoveRange; dy <= MoveRange; dy++ {
+		for dx := -MoveRange; dx <= MoveRange; dx++ {
+			p := from.Add(Point{dx, dy})
+			if r.inside(p) && from.Distance(p) <= MoveRange && p.Distance(to) < best.Distance(to) {
+				best = p
+			}
+		}
+	}
+	return best
+}
+
+//trapAt returns the trap buried in a cell, nil if there is none
+func (r *Referee) trapAt(p Point) *Entity {
+	for _, item := range r.Items {
+		if item.Item == Trap && item.Point == p {
+			return item
+		}
+	}
+	return nil
+}
+
+//explode detonates a trap, destroying robots and detonating traps next to it
+func (r *Referee) explode(trap *Entity) {
+	r.removeItem(trap)
+	for _, robot := range r.Robots {
+		if !robot.Destroyed() && robot.Distance(trap.Point) <= 1 {
+			robot.Point = Point{Nothing, Nothing}
+			robot.Item = Nothing
+		}
+	}
+	for _, next := range append([]*Entity{}, r.Items...) {
+		if next.Item == Trap && next.Distance(trap.Point) <= 1 && r.trapAt(next.Point) == next {
+			r.explode(next)
+		}
+	}
+}
+
+func (r *Referee) removeItem(item *Entity) {
+	for i, it := range r.Items {
+		if it == item {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+//dig resolves a DIG next to the robot: bury the carried item and pick up ore
+func (r *Referee) dig(robot *Entity, p Point) {
+	r.Holes[p] = true
+	if robot.Item == Radar || robot.Item == Trap {
+		r.Items = append(r.Items, &Entity{p, r.nextID, robot.EntityType, robot.Item})
+		r.nextID++
+		robot.Item = Nothing
+	}
+	if robot.Item == Nothing && r.Ore[p] > 0 {
+		r.Ore[p]--
+		robot.Item = Ore
+	}
+}
+
+//Play resolves the commands of both players in the order of the game rules, the player
+//whose robots go first on contested ore alternates every turn
+func (r *Referee) Play(commands [2][]string) {
+	orders := append(r.parseOrders(0, commands[0]), r.parseOrders(1, commands[1])...)
+	first := r.Turn % 2
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := orders[i].robot, orders[j].robot
+		if a.EntityType != b.EntityType {
+			return a.EntityType == first
+		}
+		return a.ID < b.ID
+	})
+	digging := func(o order) bool {
+		return o.DigsNow(*o.robot)
+	}
+	for _, o := range orders {
+		if digging(o) {
+			if trap := r.trapAt(o.Target); trap != nil {
+				r.explode(trap)
+			}
+		}
+	}
+	for _, o := range orders {
+		if !digging(o) {
+			continue
+		}
+		for _, item := range append([]*Entity{}, r.Items...) {
+			if item.Point == o.Target && item.Item == Radar && item.EntityType != o.robot.EntityType {
+				r.removeItem(item)
+			}
+		}
+	}
+	for _, o := range orders {
+		if digging(o) {
+			r.dig(o.robot, o.Target)
+		}
+	}
+	for _, o := range orders {
+		if o.Kind != ActionRequest || o.robot.Destroyed() || o.robot.X != 0 {
+			continue
+		}
+		player := o.robot.EntityType
+		cooldown := &r.RadarCooldown[player]
+		if o.Item == Trap {
+			cooldown = &r.TrapCooldown[player]
+		}
+		if *cooldown == 0 {
+			o.robot.Item = o.Item
+			*cooldown = ItemCooldown
+		}
+	}
+	for player := 0; player < 2; player++ {
+		for _, cooldown := range []*int{&r.RadarCooldown[player], &r.TrapCooldown[player]} {
+			if *cooldown > 0 {
+				*cooldown--
+			}
+		}
+	}
+	for _, o := range orders {
+		if (o.Kind == ActionMove || o.Kind == ActionDig) && !o.robot.Destroyed() && !digging(o) {
+			o.robot.Point = r.step(o.robot.Point, o.Target)
+		}
+	}
+	for _, robot := range r.Robots {
+		if !robot.Destroyed() && robot.X == 0 && robot.Item == Ore {
+			r.Score[robot.EntityType]++
+			robot.Item = Nothing
+		}
+	}
+	r.Turn++
+}
+
+//Over reports if the match ended: turn limit, no robots left, or no ore left to deliver
+func (r *Referee) Over() bool {
+	if r.Turn >= MaxTurns {
+		return true
+	}
+	alive, ore := false, false
+	for _, robot := range r.Robots {
+		if !robot.Destroyed() {
+			alive = true
+			ore = ore || robot.Item == Ore
+		}
+	}
+	for _, n := range r.Ore {
+		ore = ore || n > 0
+	}
+	return !alive || !ore
+}
+
+//Result returns the winning player or Draw
+func (r *Referee) Result() int {
+	switch {
+	case r.Score[0] > r.Score[1]:
+		return 0
+	case r.Score[1] > r.Score[0]:
+		return 1
+	}
+	return Draw
+}
+
+//runStrategy reads the turn input with NewTurnInput as the arena loop does and keeps it in the
+//game history, a bot that panics sends no commands so all of its robots wait
+func runStrategy(bot Strategy, game *GameObject, input string) (actions []string) {
+	defer func() {
+		if err := recover(); err != nil {
+			Debug("referee: bot crashed: %v\n", err)
+			actions = nil
+		}
+	}()
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	ti := NewTurnInput(scanner, game)
+	actions = bot(*game, &ti)
+	game.History = append(game.History, ti)
+	return actions
+}
+
+//PlayMatch runs two strategies against each other and returns the result, seed seeds both bots,
+//the input of the first player is copied to record if it is not nil
+func PlayMatch(r *Referee, bots [2]Strategy, seed int64, record io.Writer) int {
+	games := [2]GameObject{}
+	for player := range games {
+		games[player] = NewGameObject(bufio.NewScanner(strings.NewReader(r.InitInput())), seed)
+	}
+	if record != nil {
+		io.WriteString(record, r.InitInput())
+	}
+	for !r.Over() {
+		var commands [2][]string
+		for player := range commands {
+			input := r.Input(player)
+			if record != nil && player == 0 {
+				io.WriteString(record, input)
+			}
+			commands[player] = runStrategy(bots[player], &games[player], input)
+		}
+		r.Play(commands)
+	}
+	return r.Result()
+}
+
+//SelfPlay plays local matches on random maps and prints the results, games are recorded into
+//the directory record unless it is empty
+func SelfPlay(games int, bots [2]Strategy, seed int64, record string) {
+	rng := rand.New(rand.NewSource(seed))
+	wins := [3]int{}
+	for i := 0; i < games; i++ {
+		r := NewReferee(rng)
+		var w io.Writer
+		var f *os.File
+		if record != "" {
+			if err := os.MkdirAll(record, 0755); err != nil {
+				Debug("record: %v\n", err)
+				return
+			}
+			var err error
+			if f, err = os.Create(filepath.Join(record, fmt.Sprintf("game-%03d.txt", i))); err != nil {
+				Debug("record: %v\n", err)
+				return
+			}
+			w = f
+		}
+		result := PlayMatch(r, bots, seed+int64(i), w)
+		if f != nil {
+			if err := f.Close(); err != nil {
+				Debug("record: %v\n", err)
+			}
+		}
+		wins[result]++
+		fmt.Printf("game %d: result %d score %d:%d turns %d\n", i, result, r.Score[0], r.Score[1], r.Turn)
+	}
+	fmt.Printf("player 0: %d, player 1: %d, draws: %d\n", wins[0], wins[1], wins[Draw])
+}
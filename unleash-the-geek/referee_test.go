@@ -0,0 +1,116 @@
+package main
+
+import "testing"
+
+//testReferee returns an empty map with the given robots, robots are entities whose EntityType is
+//the owning player
+func testReferee(robots ...*Entity) *Referee {
+	return &Referee{
+		Width:  MapWidth,
+		Height: MapHeight,
+		Ore:    make(map[Point]int),
+		Holes:  make(map[Point]bool),
+		Robots: robots,
+		nextID: 100,
+	}
+}
+
+//bury puts an item of a player into a new hole
+func bury(r *Referee, player, item int, p Point) *Entity {
+	e := &Entity{p, r.nextID, player, item}
+	r.nextID++
+	r.Items = append(r.Items, e)
+	r.Holes[p] = true
+	return e
+}
+
+func TestRefereeTrapChain(t *testing.T) {
+	digger := &Entity{Point{4, 5}, 0, 0, Nothing}
+	neighbour := &Entity{Point{8, 5}, 1, 0, Ore}
+	clear := &Entity{Point{9, 5}, 2, 0, Nothing}
+	enemy := &Entity{Point{6, 4}, 3, 1, Nothing}
+	r := testReferee(digger, neighbour, clear, enemy)
+	for _, p := range []Point{{5, 5}, {6, 5}, {7, 5}} {
+		bury(r, 1, Trap, p)
+	}
+	radar := bury(r, 1, Radar, Point{7, 6})
+	r.Play([2][]string{{"DIG 5 5", "WAIT", "WAIT"}, {"WAIT"}})
+	for _, robot := range []*Entity{digger, neighbour, enemy} {
+		if !robot.Destroyed() || robot.Item != Nothing {
+			t.Errorf("robot %d at %v carrying %d survived the chain", robot.ID, robot.Point, robot.Item)
+		}
+	}
+	if clear.Destroyed() {
+		t.Errorf("robot %d two cells from the last trap was destroyed", clear.ID)
+	}
+	if len(r.Items) != 1 || r.Items[0] != radar {
+		t.Errorf("items after the chain %v, want only the radar", r.Items)
+	}
+}
+
+func TestRefereeDigRadar(t *testing.T) {
+	ours := &Entity{Point{5, 4}, 0, 0, Nothing}
+	theirs := &Entity{Point{8, 4}, 1, 1, Nothing}
+	r := testReferee(ours, theirs)
+	enemyRadar := bury(r, 1, Radar, Point{5, 5})
+	ownRadar := bury(r, 1, Radar, Point{8, 5})
+	r.Ore[Point{5, 5}] = 1
+	r.Play([2][]string{{"DIG 5 5"}, {"DIG 8 5"}})
+	if len(r.Items) != 1 || r.Items[0] != ownRadar {
+		t.Errorf("items %v, want the enemy dig to destroy %v and the owner's dig to keep %v", r.Items, enemyRadar.Point, ownRadar.Point)
+	}
+	if ours.Item != Ore || r.Ore[Point{5, 5}] != 0 {
+		t.Errorf("digging the radar hole carries %d, %d ore left", ours.Item, r.Ore[Point{5, 5}])
+	}
+}
+
+func TestRefereeBury(t *testing.T) {
+	robot := &Entity{Point{5, 4}, 0, 0, Trap}
+	r := testReferee(robot)
+	r.Ore[Point{5, 5}] = 2
+	r.Play([2][]string{{"DIG 5 5"}, nil})
+	if trap := r.trapAt(Point{5, 5}); trap == nil || trap.EntityType != 0 {
+		t.Errorf("no trap of player 0 buried, items %v", r.Items)
+	}
+	if robot.Item != Ore || r.Ore[Point{5, 5}] != 1 || !r.Holes[Point{5, 5}] {
+		t.Errorf("robot carries %d, %d ore left, hole %v", robot.Item, r.Ore[Point{5, 5}], r.Holes[Point{5, 5}])
+	}
+}
+
+func TestRefereeRequestCooldown(t *testing.T) {
+	first := &Entity{Point{0, 2}, 0, 0, Nothing}
+	second := &Entity{Point{0, 3}, 1, 0, Nothing}
+	away := &Entity{Point{1, 4}, 2, 0, Nothing}
+	r := testReferee(first, second, away)
+	r.Play([2][]string{{"REQUEST RADAR", "REQUEST RADAR", "REQUEST TRAP"}, nil})
+	if first.Item != Radar || second.Item != Nothing || away.Item != Nothing {
+		t.Fatalf("items after the requests %d %d %d, want only the first robot to get a radar", first.Item, second.Item, away.Item)
+	}
+	if r.RadarCooldown[0] != ItemCooldown-1 || r.TrapCooldown[0] != 0 {
+		t.Fatalf("cooldowns radar %d trap %d", r.RadarCooldown[0], r.TrapCooldown[0])
+	}
+	for turn := 1; turn < ItemCooldown; turn++ {
+		r.Play([2][]string{{"WAIT", "REQUEST RADAR", "WAIT"}, nil})
+		if second.Item != Nothing {
+			t.Fatalf("turn %d: radar handed out with cooldown %d", turn, r.RadarCooldown[0])
+		}
+	}
+	r.Play([2][]string{{"WAIT", "REQUEST RADAR", "WAIT"}, nil})
+	if second.Item != Radar {
+		t.Errorf("no radar once the cooldown ran out")
+	}
+}
+
+func TestRefereeOreDelivery(t *testing.T) {
+	carrier := &Entity{Point{3, 3}, 0, 0, Ore}
+	far := &Entity{Point{6, 3}, 1, 0, Ore}
+	enemy := &Entity{Point{0, 7}, 2, 1, Ore}
+	r := testReferee(carrier, far, enemy)
+	r.Play([2][]string{{"MOVE 0 3", "MOVE 0 3"}, {"WAIT"}})
+	if r.Score != [2]int{1, 1} {
+		t.Errorf("score %v, want one ore each", r.Score)
+	}
+	if carrier.Item != Nothing || far.Item != Ore || far.Point != (Point{2, 3}) || enemy.Item != Nothing {
+		t.Errorf("items %d %d %d, far robot at %v", carrier.Item, far.Item, enemy.Item, far.Point)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import "math"
+
+//Task kinds
+const (
+	TaskWait = iota
+	TaskReturn
+	TaskRadar
+	TaskTrap
+	TaskDig
+	TaskBlindDig
+	TaskRequestRadar
+	TaskRequestTrap
+	TaskDetonate
+)
+
+var taskNames = [...]string{"wait", "return", "radar", "trap", "dig", "blind", "request-radar", "request-trap", "detonate"}
+
+//Assignment costs in turns
+const (
+	BlindDigPenalty = 6 //digging without knowing about ore
+	CommitmentBonus = 2 //keeping last turn's target avoids thrashing
+	Unassigned      = 1 << 20
+)
+
+//Task is a duty assigned to a robot
+type Task struct {
+	Kind   int
+	Target Point
+}
+
+func (t Task) String() string {
+	if t.Kind < 0 || t.Kind >= len(taskNames) {
+		return "unknown"
+	}
+	return taskNames[t.Kind]
+}
+
+//travelTurns returns the turns a robot needs to get within reach of a target
+func travelTurns(from, to Point, reach int) int {
+	d := from.Distance(to) - reach
+	if d <= 0 {
+		return 0
+	}
+	return (d + MoveRange - 1) / MoveRange
+}
+
+//cost returns the turns a robot spends on a task, including the way back with ore
+func (t Task) cost(robot Entity) int {
+	switch t.Kind {
+	case TaskDig, TaskBlindDig:
+		return travelTurns(robot.Point, t.Target, 1) + 1 + travelTurns(t.Target, Point{0, t.Target.Y}, 0)
+	case TaskRequestRadar, TaskRequestTrap:
+		return travelTurns(robot.Point, Point{0, robot.Y}, 0) + 1
+	}
+	return 0
+}
+
+//blindTarget keeps the committed cell or picks an undug cell in the robot's row
+func (o GameObject) blindTarget(robot Entity, ti *TurnInput) Point {
+	if committed, ok := o.Commitments[robot.ID]; ok {
+		if _, hole := ti.HoleTiles[committed]; !hole && !ti.Unsafe[committed] {
+			return committed
+		}
+	}
+	if p, ok := o.priorTarget(robot, ti); ok {
+		return p
+	}
+	target := Point{1, robot.Y}
+	for {
+		target = target.Add(Point{o.random(1, MoveRange), 0})
+		if _, ok := ti.HoleTiles[target]; (!ok && !ti.Unsafe[target]) || target.X >= o.Width-1 {
+			return target.Clamp(&o)
+		}
+	}
+}
+
+//priorTarget returns the unknown cell with the most expected ore per turn of the trip according
+//to the heatmap priors, false without priors
+func (o GameObject) priorTarget(robot Entity, ti *TurnInput) (Point, bool) {
+	best, bestScore := Point{}, 0.0
+	for y := 0; y < o.Height; y++ {
+		for x := 1; x < o.Width; x++ {
+			p := Point{x, y}
+			if _, hole := ti.HoleTiles[p]; hole || ti.Unsafe[p] || ti.Suspected.Suspected(p) {
+				continue
+			}
+			if c, ok := o.Knowledge[p]; ok && c.State != Unknown {
+				continue
+			}
+			trip := travelTurns(robot.Point, p, 1) + 1 + travelTurns(p, Point{0, p.Y}, 0)
+			if score := o.Priors.At(p) / float64(trip); score > bestScore {
+				best, bestScore = p, score
+			}
+		}
+	}
+	return best, bestScore > 0
+}
+
+//digTargets returns one dig task per unit of ore we believe in, skipping our traps and suspected holes
+func (o GameObject) digTargets(ti *TurnInput) []Task {
+	tasks := make([]Task, 0)
+	for _, p := range o.Knowledge.OreTiles() {
+		if hole, ok := ti.HoleTiles[p]; (ok && hole == MyTrap) || ti.Suspected.Suspected(p) || ti.Unsafe[p] {
+			continue
+		}
+		units := 1
+		if c := o.Knowledge[p]; c.State == KnownOre {
+			units = c.Ore
+		}
+		for i := 0; i < units && i < len(ti.MyRobots); i++ {
+			tasks = append(tasks, Task{TaskDig, p})
+		}
+	}
+	return tasks
+}
+
+//AssignTasks solves the assignment of idle robots to digs and item requests jointly, robots
+//carrying something keep their duty
+func (o GameObject) AssignTasks(ti *TurnInput) map[int]Task {
+	assigned := make(map[int]Task)
+	idle := make([]Entity, 0)
+	for _, r := range ti.PlayerRobots() {
+		trap, detonate := ti.Detonations[r.ID]
+		switch {
+		case r.Destroyed():
+			assigned[r.ID] = Task{Kind: TaskWait}
+		case detonate:
+			assigned[r.ID] = Task{TaskDetonate, trap}
+		case r.Item == Ore:
+			assigned[r.ID] = Task{TaskReturn, Point{0, r.Y}}
+		case r.Item == Radar:
+			assigned[r.ID] = Task{Kind: TaskRadar}
+		case r.Item == Trap:
+			assigned[r.ID] = Task{Kind: TaskTrap}
+		default:
+			idle = append(idle, r)
+		}
+	}
+	tasks := o.digTargets(ti)
+	if ti.RadarHolder == Nothing && ti.RadarCooldown == 0 && (len(tasks) < 2*len(ti.MyRobots) || len(o.LostRadars(ti)) > 0) {
+		tasks = append(tasks, Task{Kind: TaskRequestRadar})
+	}
+	if ti.TrapHolder == Nothing && ti.TrapCooldown == 0 {
+		tasks = append(tasks, Task{Kind: TaskRequestTrap})
+	}
+	shared := len(tasks)
+	for _, r := range idle {
+		tasks = append(tasks, Task{TaskBlindDig, o.blindTarget(r, ti)})
+	}
+	cost := make([][]int, len(idle))
+	for i, r := range idle {
+		cost[i] = make([]int, len(tasks))
+		for j, t := range tasks {
+			switch {
+			case j >= shared && j-shared != i:
+				cost[i][j] = Unassigned
+				continue
+			case t.Kind == TaskBlindDig:
+				cost[i][j] = t.cost(r) + BlindDigPenalty
+			case t.Kind == TaskDig:
+				cost[i][j] = t.cost(r) + int(math.Round(BlindDigPenalty*(1-o.Knowledge[t.Target].Belief)))
+			default:
+				cost[i][j] = t.cost(r)
+			}
+			if committed, ok := o.Commitments[r.ID]; ok && (t.Kind == TaskDig || t.Kind == TaskBlindDig) && committed == t.Target {
+				cost[i][j] -= CommitmentBonus
+			}
+		}
+	}
+	for i, j := range Hungarian(cost) {
+		task := tasks[j]
+		assigned[idle[i].ID] = task
+		switch task.Kind {
+		case TaskRequestRadar:
+			ti.RadarHolder = idle[i].ID
+		case TaskRequestTrap:
+			ti.TrapHolder = idle[i].ID
+		}
+	}
+	for id, task := range assigned {
+		if task.Kind == TaskDig || task.Kind == TaskBlindDig {
+			o.Commitments[id] = task.Target
+		} else {
+			delete(o.Commitments, id)
+		}
+	}
+	return assigned
+}
+
+//Hungarian solves the rectangular assignment problem with at most as many rows as columns,
+//it returns the column of every row
+func Hungarian(cost [][]int) []int {
+	n := len(cost)
+	if n == 0 {
+		return []int{}
+	}
+	m := len(cost[0])
+	u, v := make([]int, n+1), make([]int, m+1)
+	p, way := make([]int, m+1), make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]int, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.MaxInt32
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.MaxInt32, 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}